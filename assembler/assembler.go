@@ -3,32 +3,51 @@ package assembler
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"svm/bytecode"
 )
 
 func Assemble(file string) ([]byte, error) {
+	program, err := AssembleProgram(file)
+	if err != nil {
+		return nil, err
+	}
+	return program.Code, nil
+}
+
+// ասեմբլացնել file ֆայլում գրված ծրագիրը՝ պահպանելով պիտակներն ու
+// հրամանների տողերը
+func AssembleProgram(file string) (*bytecode.Program, error) {
 	// բացել ֆայլը
 	input, err := os.Open(file)
 	if err != nil {
 		return nil, fmt.Errorf("Չհաջողվեց բացել ծրագրի տեքստի ֆայլը։")
 	}
 	defer input.Close()
+	return AssembleReader(input)
+}
 
+// ասեմբլացնել source-ից կարդացված ծրագրի տեքստը
+func AssembleReader(source io.Reader) (*bytecode.Program, error) {
 	// վերլուծել ծրագիրն ու կառուցել բայթկոդը
 	p := &parser{
 		sc: &scanner{
-			source: bufio.NewReader(input),
+			source: bufio.NewReader(source),
 			line:   1,
 		},
 		builder: bytecode.NewBuilder(),
 	}
-	err = p.parse()
+	err := p.parse()
 	if err != nil {
 		return nil, err
 	}
 
-	p.builder.Validate() // լուծել անորոշ հղումները
+	// լուծել անորոշ հղումները
+	if !p.builder.Validate() {
+		return nil, fmt.Errorf("ՍԽԱԼ: Պիտակը սահմանված չէ. %s", strings.Join(p.builder.Undefined(), ", "))
+	}
 
-	return p.builder.Bytes(), nil
+	return p.builder.Program(), nil
 }
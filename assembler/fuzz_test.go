@@ -0,0 +1,112 @@
+package assembler
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"svm/bytecode"
+	"testing"
+)
+
+// examples պանակի ծրագրերը՝ որպես սկզբնական տվյալներ
+func addExamples(f *testing.F) {
+	files, _ := filepath.Glob("../examples/*.asm")
+	for _, file := range files {
+		if text, err := os.ReadFile(file); err == nil {
+			f.Add(string(text))
+		}
+	}
+	f.Add("  PUSH entry\nentry:\n  JUMPTAB cases\ncases:\n  .jumptable entry, cases\n")
+	f.Add("  TAILCALL f, 1, 2\nf:\n  RET 1\n")
+	f.Add("  RET -2\n  HALT -1\n")
+}
+
+// ասեմբլացնել text-ը
+func assembleText(text string) (*bytecode.Builder, error) {
+	p := createParserFor(text)
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	if !p.builder.Validate() {
+		return nil, fmt.Errorf("Պիտակը սահմանված չէ. %v", p.builder.Undefined())
+	}
+	return p.builder, nil
+}
+
+// text-ում կա՞ .jumptable աղյուսակ, որի հասցեին չի դիմում ոչ մի
+// JUMPTAB հրաման
+func unreferencedTables(text string, symbols map[string]int) bool {
+	sc := &scanner{source: bufio.NewReader(strings.NewReader(text)), line: 1}
+	referenced := map[int]bool{}
+	tables := []int{}
+	labels := []string{} // հաջորդ հրամանի կամ աղյուսակի պիտակները
+	previous := lexeme{}
+	for current := sc.scanOne(); current.kind != xEos; current = sc.scanOne() {
+		switch {
+		case current.kind == xColon && previous.kind == xIdent:
+			labels = append(labels, previous.value)
+		case current.kind == xIdent && previous.kind == xOperation && previous.value == "JUMPTAB":
+			referenced[symbols[current.value]] = true
+		case current.kind == xDirective && current.value == ".jumptable":
+			// առանց պիտակի աղյուսակին դիմել հնարավոր չէ
+			address := -1
+			if len(labels) > 0 {
+				address = symbols[labels[0]]
+			}
+			tables = append(tables, address)
+			labels = labels[:0]
+		case current.kind == xOperation:
+			labels = labels[:0]
+		}
+		previous = current
+	}
+	for _, address := range tables {
+		if !referenced[address] {
+			return true
+		}
+	}
+	return false
+}
+
+func FuzzAssemble(f *testing.F) {
+	addExamples(f)
+	f.Fuzz(func(t *testing.T, text string) {
+		builder, err := assembleText(text)
+		if err == nil {
+			builder.Bytes()
+		}
+	})
+}
+
+// ասեմբլացված ծրագիրը ապաասեմբլացնելուց և նորից ասեմբլացնելուց
+// հետո ստացվում է նույն բայթկոդը
+func FuzzRoundTrip(f *testing.F) {
+	addExamples(f)
+	f.Fuzz(func(t *testing.T, text string) {
+		builder, err := assembleText(text)
+		if err != nil {
+			return
+		}
+		code := builder.Bytes()
+
+		var listing bytes.Buffer
+		if err := bytecode.Disassemble(&listing, code, builder.Symbols()); err != nil {
+			t.Fatalf("Ապաասեմբլերի սխալ։ (%v)\n%s", err, text)
+		}
+		// աղյուսակները, որոնց չի դիմում JUMPTAB, ապաասեմբլերը
+		// տարբերել չի կարող հրամաններից
+		if unreferencedTables(text, builder.Symbols()) {
+			return
+		}
+		again, err := assembleText(listing.String())
+		if err != nil {
+			t.Fatalf("Ասեմբլերի սխալ։ (%v)\n%s", err, listing.String())
+		}
+		if !bytes.Equal(code, again.Bytes()) {
+			t.Fatalf("Սպասվում էր %v, ստացվել է %v\n%s", code, again.Bytes(), listing.String())
+		}
+	})
+}
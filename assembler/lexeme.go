@@ -16,6 +16,8 @@ const (
 	xRightBr
 	xPlus
 	xMinus
+	xComma
+	xDirective
 	xEos
 )
 
@@ -31,6 +33,8 @@ var tokenNames = map[token]string{
 	xRightBr:   "]",
 	xPlus:      "+",
 	xMinus:     "-",
+	xComma:     ",",
+	xDirective: "Directive",
 	xEos:       "Eos",
 }
 
@@ -56,6 +60,9 @@ func (l lexeme) String() string {
 	if l.kind == xRegister {
 		return fmt.Sprintf("REG<%s>", l.value)
 	}
+	if l.kind == xDirective {
+		return fmt.Sprintf("DIR<%s>", l.value)
+	}
 	if l.kind == xNumber {
 		return fmt.Sprintf("NUM<%s>", l.value)
 	}
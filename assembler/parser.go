@@ -8,31 +8,62 @@ import (
 )
 
 var operations = map[string]byte{
-	"NOP":   bytecode.Nop,
-	"PUSH":  bytecode.Push,
-	"POP":   bytecode.Pop,
-	"CALL":  bytecode.Call,
-	"RET":   bytecode.Ret,
-	"JUMP":  bytecode.Jump,
-	"JZ":    bytecode.Jz,
-	"HALT":  bytecode.Halt,
-	"ADD":   bytecode.Add,
-	"SUB":   bytecode.Sub,
-	"MUL":   bytecode.Mul,
-	"DIV":   bytecode.Div,
-	"MOD":   bytecode.Mod,
-	"NEG":   bytecode.Neg,
-	"AND":   bytecode.And,
-	"OR":    bytecode.Or,
-	"NOT":   bytecode.Not,
-	"EQ":    bytecode.Eq,
-	"NE":    bytecode.Ne,
-	"LT":    bytecode.Lt,
-	"LE":    bytecode.Le,
-	"GT":    bytecode.Gt,
-	"GE":    bytecode.Ge,
-	"INPUT": bytecode.Input,
-	"PRINT": bytecode.Print,
+	"NOP":      bytecode.Nop,
+	"PUSH":     bytecode.Push,
+	"POP":      bytecode.Pop,
+	"CALL":     bytecode.Call,
+	"RET":      bytecode.Ret,
+	"JUMP":     bytecode.Jump,
+	"JZ":       bytecode.Jz,
+	"HALT":     bytecode.Halt,
+	"ADD":      bytecode.Add,
+	"SUB":      bytecode.Sub,
+	"MUL":      bytecode.Mul,
+	"DIV":      bytecode.Div,
+	"MOD":      bytecode.Mod,
+	"NEG":      bytecode.Neg,
+	"AND":      bytecode.And,
+	"OR":       bytecode.Or,
+	"NOT":      bytecode.Not,
+	"EQ":       bytecode.Eq,
+	"NE":       bytecode.Ne,
+	"LT":       bytecode.Lt,
+	"LE":       bytecode.Le,
+	"GT":       bytecode.Gt,
+	"GE":       bytecode.Ge,
+	"INPUT":    bytecode.Input,
+	"PRINT":    bytecode.Print,
+	"LOAD":     bytecode.Load,
+	"STORE":    bytecode.Store,
+	"LOADB":    bytecode.LoadB,
+	"STOREB":   bytecode.StoreB,
+	"ENTER":    bytecode.Enter,
+	"LEAVE":    bytecode.Leave,
+	"TRY":      bytecode.Try,
+	"ENDTRY":   bytecode.EndTry,
+	"THROW":    bytecode.Throw,
+	"TAILCALL": bytecode.TailCall,
+	"ADDO":     bytecode.AddO,
+	"SUBO":     bytecode.SubO,
+	"MULO":     bytecode.MulO,
+	"NEGO":     bytecode.NegO,
+	"JUMPTAB":  bytecode.JumpTab,
+	"MEMCPY":   bytecode.MemCpy,
+	"MEMSET":   bytecode.MemSet,
+	"MEMCMP":   bytecode.MemCmp,
+	"NEW":      bytecode.New,
+	"GC":       bytecode.GC,
+	"EPRINT":   bytecode.EPrint,
+	"SYS":      bytecode.Sys,
+	"GETCR":    bytecode.GetCR,
+	"SETCR":    bytecode.SetCR,
+	"IRET":     bytecode.IRet,
+	"SYSENTER": bytecode.SysEnter,
+	"SPAWN":    bytecode.Spawn,
+	"CAS":      bytecode.Cas,
+	"FETCHADD": bytecode.FetchAdd,
+	"FENCE":    bytecode.Fence,
+	"ASSERT":   bytecode.Assert,
 }
 
 var registers = map[string]uint16{
@@ -72,6 +103,7 @@ func (p *parser) parseNewLines() {
 
 // տեքստի մեկ տողի վերլուծությունը
 func (p *parser) parseLine() error {
+	p.builder.SetLine(p.sc.line)
 	if p.has(xIdent) {
 		err := p.parseLabel()
 		if err != nil {
@@ -84,6 +116,11 @@ func (p *parser) parseLine() error {
 		if err != nil {
 			return err
 		}
+	} else if p.has(xDirective) {
+		err := p.parseDirective()
+		if err != nil {
+			return err
+		}
 	}
 
 	if p.has(xNewLine) {
@@ -105,20 +142,32 @@ func (p *parser) parseOperation() error {
 		return p.parsePush()
 	case "POP":
 		return p.parsePop()
-	case "CALL", "JUMP", "JZ":
+	case "CALL", "JUMP", "JZ", "TRY", "JUMPTAB", "SPAWN":
 		return p.parseJump()
-	case "HALT", "RET", "ADD", "SUB", "MUL",
+	case "RET", "NEW", "HALT":
+		return p.parseOptionalNumber()
+	case "ENTER", "SYS", "GETCR", "SETCR":
+		return p.parseCount()
+	case "TAILCALL":
+		return p.parseTailCall()
+	case "ADD", "SUB", "MUL",
 		"DIV", "MOD", "NEG", "AND", "OR",
 		"NOT", "EQ", "NE", "LT", "LE",
-		"GT", "GE", "INPUT", "PRINT":
+		"GT", "GE", "INPUT", "PRINT", "LOAD",
+		"STORE", "LOADB", "STOREB", "LEAVE",
+		"ENDTRY", "THROW", "ADDO", "SUBO", "MULO",
+		"NEGO", "MEMCPY", "MEMSET", "MEMCMP", "GC",
+		"EPRINT", "IRET", "SYSENTER", "CAS", "FETCHADD", "FENCE", "NOP",
+		"ASSERT":
 		return p.parseSimple()
 	}
 
 	return nil
 }
 
-// PUSH-ը հանդիպում է երկու տեսքով, անմիջական թվային արգումենտով
-// և անուղղակի հասցեավորմամբ, օրինակ՝ PUSH [SP+4]
+// PUSH-ը հանդիպում է երեք տեսքով, անմիջական թվային արգումենտով,
+// անուղղակի հասցեավորմամբ, օրինակ՝ PUSH [SP+4], և պիտակով, որի
+// դեպքում ստեկում գրվում է պիտակի հասցեն, օրինակ՝ PUSH handler
 func (p *parser) parsePush() error {
 	name, err := p.match(xOperation)
 	if err != nil {
@@ -140,6 +189,11 @@ func (p *parser) parsePush() error {
 			return err
 		}
 		p.builder.AddWithAddress(bytecode.Push, register, displacement)
+	} else if p.has(xIdent) {
+		label, _ := p.match(xIdent)
+		p.builder.AddLabelAddress(bytecode.Push, label)
+	} else {
+		return p.report("PUSH հրահանգը սպասում է թիվ, անուղղակի հասցե կամ պիտակ")
 	}
 
 	return nil
@@ -168,14 +222,14 @@ func (p *parser) parsePop() error {
 }
 
 // վերլուծվում են անցում կատարող բոլոր գործողությունները.
-// CALL, JUMP, JZ; Դրանց բոլորի արգումենտը պիտակ է
+// CALL, JUMP, JZ, TRY, JUMPTAB, SPAWN; Դրանց բոլորի արգումենտը պիտակ է
 func (p *parser) parseJump() error {
 	name, err := p.match(xOperation)
 	if err != nil {
 		return err
 	}
-	if name != "CALL" && name != "JUMP" && name != "JZ" {
-		return p.report("Սպասվում է CALL, JUMP կամ JZ, բայց ստացվել է %s", name)
+	if !slices.Contains([]string{"CALL", "JUMP", "JZ", "TRY", "JUMPTAB", "SPAWN"}, name) {
+		return p.report("Սպասվում է CALL, JUMP, JZ, TRY, JUMPTAB կամ SPAWN, բայց ստացվել է %s", name)
 	}
 
 	label, err := p.match(xIdent)
@@ -187,6 +241,121 @@ func (p *parser) parseJump() error {
 	return nil
 }
 
+// RET-ը, NEW-ն և HALT-ը կարող են ունենալ թվային արգումենտ. RET-ի
+// համար դա ստեկից հեռացվող արգումենտների քանակն է, օրինակ՝ RET 2,
+// NEW-ի համար՝ օբյեկտի սլոտների քանակը, որն առանց արգումենտի վերցվում
+// է ստեկից, իսկ HALT-ի համար՝ ծրագրի ավարտի կոդը
+func (p *parser) parseOptionalNumber() error {
+	name, err := p.match(xOperation)
+	if err != nil {
+		return err
+	}
+
+	// արգումենտը կարող է լինել նշանով, օրինակ՝ HALT -1
+	if p.has(xNumber) || p.has(xMinus) || p.has(xPlus) {
+		number, err := p.parseNumber()
+		if err != nil {
+			return err
+		}
+		p.builder.AddWithNumeric(operations[name], number)
+		return nil
+	}
+
+	p.builder.AddBasic(operations[name])
+	return nil
+}
+
+// ENTER-ի արգումենտը լոկալ փոփոխականների քանակն է, օրինակ՝ ENTER 3,
+// SYS-ինը՝ համակարգային կանչի համարը, օրինակ՝ SYS 1, իսկ GETCR-ինն
+// ու SETCR-ինը՝ կառավարման ռեգիստրի համարը, օրինակ՝ SETCR 0
+func (p *parser) parseCount() error {
+	name, err := p.match(xOperation)
+	if err != nil {
+		return err
+	}
+
+	count, err := p.parseNumber()
+	if err != nil {
+		return err
+	}
+	if count < 0 {
+		return p.report("%s-ի արգումենտը չի կարող բացասական լինել", name)
+	}
+	p.builder.AddWithNumeric(operations[name], count)
+	return nil
+}
+
+// TAILCALL-ի արգումենտներն են կանչվող ենթածրագրի պիտակը, դրա
+// արգումենտների քանակը և ընթացիկ ենթածրագրի արգումենտների քանակը,
+// օրինակ՝ TAILCALL loop, 2, 2
+func (p *parser) parseTailCall() error {
+	_, err := p.match(xOperation)
+	if err != nil {
+		return err
+	}
+
+	label, err := p.match(xIdent)
+	if err != nil {
+		return err
+	}
+
+	counts := make([]byte, 2)
+	for i := range counts {
+		_, err = p.match(xComma)
+		if err != nil {
+			return err
+		}
+		count, err := p.parseNumber()
+		if err != nil {
+			return err
+		}
+		if count < 0 || count > 255 {
+			return p.report("TAILCALL-ի արգումենտների քանակը պետք է լինի 0-ից 255")
+		}
+		counts[i] = byte(count)
+	}
+
+	p.builder.AddTailCall(label, counts[0], counts[1])
+	return nil
+}
+
+// ասեմբլերի հրահանգներ
+func (p *parser) parseDirective() error {
+	name, err := p.match(xDirective)
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case ".jumptable":
+		return p.parseJumpTable()
+	}
+
+	return p.report("Անծանոթ հրահանգ %s", name)
+}
+
+// անցումների աղյուսակ. առաջինը լռելյայն պիտակն է, հետո՝ դեպքերի
+// պիտակները, օրինակ՝ .jumptable other, zero, one, two
+func (p *parser) parseJumpTable() error {
+	fallback, err := p.match(xIdent)
+	if err != nil {
+		return err
+	}
+
+	labels := []string{}
+	for p.has(xComma) {
+		p.match(xComma)
+		label, err := p.match(xIdent)
+		if err != nil {
+			return err
+		}
+		labels = append(labels, label)
+	}
+
+	p.builder.AddJumpTable(fallback, labels...)
+	return nil
+}
+
 // արգումենտներ չունեցող գործողություններ
 func (p *parser) parseSimple() error {
 	name, err := p.match(xOperation)
@@ -73,3 +73,188 @@ func TestErrorHandling(t *testing.T) {
 		t.Errorf("Սպասվում է \"%s\" հաղորդագրությունը\n", expected0)
 	}
 }
+
+func TestParseFrameInstructions(t *testing.T) {
+	example0 := `f:
+	  ENTER 2
+	  LEAVE
+	  RET 3
+	  RET
+	`
+
+	p := createParserFor(example0)
+	if err := p.parse(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	buffer := bytes.NewBufferString("")
+	p.builder.Dump(buffer)
+	generated := buffer.String()
+
+	expected := "0000 5d 02 00 00 00\n" +
+		"0005 1e\n" +
+		"0006 44 03 00 00 00\n" +
+		"000b 04\n"
+	if expected != generated {
+		t.Errorf("Ստացված բայթկոդը չի հմապատասխանում սպասվածին։\n|%s|\n\n|%s|", expected, generated)
+	}
+
+	p = createParserFor("ENTER -1\n")
+	if err := p.parse(); err == nil {
+		t.Errorf("ENTER-ի բացասական արգումենտի համար սպասվում է սխալ")
+	}
+}
+
+func TestParseTailCall(t *testing.T) {
+	example0 := `loop:
+	  TAILCALL loop, 2, 3
+	`
+
+	p := createParserFor(example0)
+	if err := p.parse(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	p.builder.Validate()
+
+	expected := []byte{0x62, 0x00, 0x00, 0x02, 0x03}
+	if generated := p.builder.Bytes(); !bytes.Equal(expected, generated) {
+		t.Errorf("Սպասվում էր '%v', ստացվել է '%v'", expected, generated)
+	}
+
+	p = createParserFor("TAILCALL loop, 256, 0\n")
+	if err := p.parse(); err == nil {
+		t.Errorf("Արգումենտների չափազանց մեծ քանակի համար սպասվում է սխալ")
+	}
+}
+
+func TestParseJumpTable(t *testing.T) {
+	example0 := `  JUMPTAB cases
+	zero:
+	  HALT
+	cases:
+	  .jumptable zero, zero, cases
+	`
+
+	p := createParserFor(example0)
+	if err := p.parse(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	p.builder.Validate()
+
+	expected := []byte{0xa7, 0x04, 0x00, 0x07, 0x02, 0x00, 0x03, 0x00, 0x03, 0x00, 0x04, 0x00}
+	if generated := p.builder.Bytes(); !bytes.Equal(expected, generated) {
+		t.Errorf("Սպասվում էր '%v', ստացվել է '%v'", expected, generated)
+	}
+
+	p = createParserFor(".table zero\n")
+	if err := p.parse(); err == nil {
+		t.Errorf("Անծանոթ հրահանգի համար սպասվում է սխալ")
+	}
+}
+
+func TestParseLabelAddress(t *testing.T) {
+	example0 := `  PUSH entry
+entry:
+  SYSENTER
+`
+
+	p := createParserFor(example0)
+	if err := p.parse(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	p.builder.Validate()
+
+	expected := []byte{0x41, 0x05, 0x00, 0x00, 0x00, 0x32}
+	if generated := p.builder.Bytes(); !bytes.Equal(expected, generated) {
+		t.Errorf("Սպասվում էր '%v', ստացվել է '%v'", expected, generated)
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	example0 := `; երկու թվերից մեծը
+  INPUT
+
+loop:  ; ցիկլ
+  JZ loop
+  .jumptable loop, loop
+  HALT
+`
+
+	p := createParserFor(example0)
+	if err := p.parse(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	expected := map[int]int{0: 2, 1: 5, 10: 7}
+	lines := p.builder.Lines()
+	if len(lines) != len(expected) {
+		t.Errorf("Սպասվում էր %v, ստացվել է %v", expected, lines)
+	}
+	for address, line := range expected {
+		if lines[address] != line {
+			t.Errorf("%d հասցեի համար սպասվում էր %d տողը, ստացվել է %d", address, line, lines[address])
+		}
+	}
+}
+
+func TestParseSpawn(t *testing.T) {
+	example0 := `  SPAWN worker
+worker:
+  FENCE
+`
+
+	p := createParserFor(example0)
+	if err := p.parse(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	p.builder.Validate()
+
+	expected := []byte{0xb3, 0x03, 0x00, 0x36}
+	if generated := p.builder.Bytes(); !bytes.Equal(expected, generated) {
+		t.Errorf("Սպասվում էր '%v', ստացվել է '%v'", expected, generated)
+	}
+}
+
+func TestUndefinedLabel(t *testing.T) {
+	p := createParserFor("  JUMP nowhere\n  CALL f\nf:\n  RET\n")
+	if err := p.parse(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if p.builder.Validate() {
+		t.Errorf("Չսահմանված պիտակի համար սպասվում է սխալ")
+	}
+	if undefined := p.builder.Undefined(); len(undefined) != 1 || undefined[0] != "nowhere" {
+		t.Errorf("Սպասվում է [nowhere], բայց ստացվել է %v", undefined)
+	}
+}
+
+func TestPushWithoutOperand(t *testing.T) {
+	if err := createParserFor("  PUSH\n").parse(); err == nil {
+		t.Errorf("Առանց արգումենտի PUSH-ի համար սպասվում է սխալ")
+	}
+}
+
+func TestParseNop(t *testing.T) {
+	p := createParserFor("  NOP\n")
+	if err := p.parse(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	expected := []byte{bytecode.Nop}
+	if generated := p.builder.Bytes(); !bytes.Equal(expected, generated) {
+		t.Errorf("Սպասվում էր '%v', ստացվել է '%v'", expected, generated)
+	}
+}
+
+func TestParseNegativeOperand(t *testing.T) {
+	p := createParserFor("  HALT -1\n  RET -2\n")
+	if err := p.parse(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	expected := []byte{
+		bytecode.Halt | bytecode.Immediate, 0xff, 0xff, 0xff, 0xff,
+		bytecode.Ret | bytecode.Immediate, 0xfe, 0xff, 0xff, 0xff,
+	}
+	if generated := p.builder.Bytes(); !bytes.Equal(expected, generated) {
+		t.Errorf("Սպասվում էր '%v', ստացվել է '%v'", expected, generated)
+	}
+}
@@ -16,6 +16,7 @@ var metasymbols = map[rune]token{
 	']':  xRightBr,
 	'+':  xPlus,
 	'-':  xMinus,
+	',':  xComma,
 	'\n': xNewLine,
 }
 
@@ -54,6 +55,12 @@ func (s *scanner) scanOne() lexeme {
 		return lexeme{kind: xIdent, value: text}
 	}
 
+	// ասեմբլերի հրահանգ, օրինակ՝ .jumptable
+	if ch == '.' {
+		text := s.readCharsWhile(isAlphaNumeric)
+		return lexeme{kind: xDirective, value: "." + text}
+	}
+
 	// ամբողջ թիվ
 	if unicode.IsDigit(ch) {
 		s.source.UnreadRune()
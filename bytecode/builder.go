@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"strings"
 )
 
@@ -13,9 +15,16 @@ type instruction struct {
 	opcode    byte   // կոդը և տեսակը
 	immediate int32  // թվային արգումենտ
 	indirect  uint16 // անուղղակի հասցե
+	line      int    // ծրագրի տեքստի տողը, 0՝ եթե հայտնի չէ
+
+	table []uint16 // անցումների աղյուսակ՝ լռելյայն հասցեն և դեպքերը
 }
 
 func (i *instruction) size() int {
+	if i.table != nil {
+		return 2 + 2*len(i.table)
+	}
+
 	var value int = 1
 	switch i.opcode & 0xC0 {
 	case Immediate:
@@ -28,6 +37,14 @@ func (i *instruction) size() int {
 
 func (i *instruction) bytes() []byte {
 	result := make([]byte, i.size())
+	if i.table != nil {
+		binary.LittleEndian.PutUint16(result, uint16(len(i.table)-1))
+		for k, address := range i.table {
+			binary.LittleEndian.PutUint16(result[2+2*k:], address)
+		}
+		return result
+	}
+
 	result[0] = i.opcode
 	switch i.opcode & 0xC0 {
 	case Immediate:
@@ -50,9 +67,11 @@ type Builder struct {
 	instructions []*instruction // հրամանների ցուցակ
 	count        int            // հրամանների հաշվիչ
 
-	labels     map[string]int          // պիտակներ, ժամանակավոր
-	unresolved map[*instruction]string // ժամանակավորապես անհասցե պիտակներ
-	offset     int                     // ընթացիկ շեղումը 0-ից
+	labels     map[string]int            // պիտակներ, ժամանակավոր
+	unresolved map[*instruction]string   // ժամանակավորապես անհասցե պիտակներ
+	tables     map[*instruction][]string // անցումների աղյուսակների պիտակներ
+	offset     int                       // ընթացիկ շեղումը 0-ից
+	line       int                       // ընթացիկ տողը ծրագրի տեքստում
 }
 
 func NewBuilder() *Builder {
@@ -60,6 +79,7 @@ func NewBuilder() *Builder {
 		instructions: make([]*instruction, 0),
 		labels:       make(map[string]int),
 		unresolved:   make(map[*instruction]string),
+		tables:       make(map[*instruction][]string),
 	}
 }
 
@@ -71,6 +91,32 @@ func (b *Builder) Bytes() []byte {
 	return buffer.Bytes()
 }
 
+// պիտակների հասցեները
+func (b *Builder) Symbols() map[string]int {
+	return maps.Clone(b.labels)
+}
+
+// հետագա հրամանները համապատասխանում են ծրագրի տեքստի line տողին
+func (b *Builder) SetLine(line int) {
+	b.line = line
+}
+
+// ամեն հրամանի հասցեին համապատասխանող ծրագրի տեքստի տողը
+func (b *Builder) Lines() map[int]int {
+	lines := make(map[int]int)
+	for _, instr := range b.instructions {
+		if instr.table == nil && instr.line != 0 {
+			lines[instr.address] = instr.line
+		}
+	}
+	return lines
+}
+
+// կառուցված ծրագիրը՝ բայթկոդը, պիտակներն ու տողերը
+func (b *Builder) Program() *Program {
+	return &Program{Code: b.Bytes(), Symbols: b.Symbols(), Lines: b.Lines()}
+}
+
 func (b *Builder) SetLabel(name string) {
 	if _, exists := b.labels[name]; !exists {
 		b.labels[name] = b.offset
@@ -93,7 +139,7 @@ func (b *Builder) AddWithNumeric(opcode byte, number int32) {
 func (b *Builder) AddWithAddress(opcode byte, register uint16, displacement int16) {
 	instr := &instruction{}
 	instr.opcode = opcode | Indirect
-	instr.indirect = register | uint16(displacement)
+	instr.indirect = register | uint16(displacement)&0x3FFF
 	b.addInstruction(instr)
 }
 
@@ -104,19 +150,81 @@ func (b *Builder) AddWithLabel(opcode byte, label string) {
 	b.addInstruction(instr)
 }
 
+// ավելացնել հրաման, որի անմիջական արգումենտը label պիտակի հասցեն է
+func (b *Builder) AddLabelAddress(opcode byte, label string) {
+	instr := &instruction{}
+	instr.opcode = opcode | Immediate
+	b.unresolved[instr] = label
+	b.addInstruction(instr)
+}
+
+// TAILCALL հրամանի արգումենտը կազմված է պիտակի հասցեից (ցածր 16 բիթերը),
+// կանչվող ենթածրագրի արգումենտների քանակից (հաջորդ 8 բիթերը) և
+// ընթացիկ ենթածրագրի արգումենտների քանակից (բարձր 8 բիթերը)
+func (b *Builder) AddTailCall(label string, arguments, parameters byte) {
+	instr := &instruction{}
+	instr.opcode = TailCall | Immediate
+	instr.immediate = int32(uint32(arguments)<<16 | uint32(parameters)<<24)
+	b.unresolved[instr] = label
+	b.addInstruction(instr)
+}
+
+// JUMPTAB հրամանի համար անցումների աղյուսակ. առաջինը լռելյայն
+// պիտակն է, որին անցումը կատարվում է, երբ ինդեքսը դուրս է
+// աղյուսակի սահմաններից, իսկ հետո՝ 0, 1, ... ինդեքսների պիտակները
+func (b *Builder) AddJumpTable(fallback string, labels ...string) {
+	instr := &instruction{}
+	instr.table = make([]uint16, 1+len(labels))
+	b.tables[instr] = append([]string{fallback}, labels...)
+	b.addInstruction(instr)
+}
+
 func (b *Builder) addInstruction(instr *instruction) {
 	instr.address = b.offset
+	instr.line = b.line
 	b.offset += instr.size()
 	b.instructions = append(b.instructions, instr)
 	b.count++
 }
 
+// հղված, բայց չսահմանված պիտակները
+func (b *Builder) Undefined() []string {
+	undefined := []string{}
+	check := func(label string) {
+		if _, exists := b.labels[label]; !exists && !slices.Contains(undefined, label) {
+			undefined = append(undefined, label)
+		}
+	}
+	for _, label := range b.unresolved {
+		check(label)
+	}
+	for _, labels := range b.tables {
+		for _, label := range labels {
+			check(label)
+		}
+	}
+	slices.Sort(undefined)
+	return undefined
+}
+
+// լուծել պիտակներին հղումները. վերադարձնում է false, եթե հղված
+// պիտակներից որևէ մեկը սահմանված չէ (տես Undefined)
 func (b *Builder) Validate() bool {
 	// լրացնել անորոշ հղումները
 	for instr, label := range b.unresolved {
-		instr.indirect = uint16(b.labels[label])
+		address := uint16(b.labels[label])
+		if instr.opcode&0xC0 == Immediate {
+			instr.immediate = instr.immediate&^0xFFFF | int32(address)
+		} else {
+			instr.indirect = address
+		}
+	}
+	for instr, labels := range b.tables {
+		for k, label := range labels {
+			instr.table[k] = uint16(b.labels[label])
+		}
 	}
-	return true
+	return len(b.Undefined()) == 0
 }
 
 // func (b *Builder) PushI(number int32) {}
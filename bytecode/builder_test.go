@@ -32,3 +32,15 @@ func TestLabledInstructions(t *testing.T) {
 	builder.Validate()
 	builder.Dump(os.Stdout)
 }
+
+func TestNegativeDisplacement(t *testing.T) {
+	builder := NewBuilder()
+	builder.AddWithAddress(Push, FramePointer, -12)
+	builder.AddWithAddress(Pop, StackPointer, 8)
+	bc := builder.Bytes()
+
+	expected := []byte{0x81, 0xf4, 0xbf, 0x82, 0x08, 0x40}
+	if !bytes.Equal(expected, bc) {
+		t.Errorf("Սպասվում էր '%v', ստացվել է '%v'", expected, bc)
+	}
+}
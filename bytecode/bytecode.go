@@ -26,6 +26,37 @@ const (
 	Le
 	Gt
 	Ge
+	Load
+	Store
+	LoadB
+	StoreB
+	Enter
+	Leave
+	Try
+	EndTry
+	Throw
+	TailCall
+	AddO
+	SubO
+	MulO
+	NegO
+	JumpTab
+	MemCpy
+	MemSet
+	MemCmp
+	New
+	GC
+	EPrint
+	Sys
+	GetCR
+	SetCR
+	IRet
+	SysEnter
+	Spawn
+	Cas
+	FetchAdd
+	Fence
+	Assert
 )
 
 var Codes = []byte{
@@ -54,34 +85,96 @@ var Codes = []byte{
 	Le,
 	Gt,
 	Ge,
+	Load,
+	Store,
+	LoadB,
+	StoreB,
+	Enter,
+	Leave,
+	Try,
+	EndTry,
+	Throw,
+	TailCall,
+	AddO,
+	SubO,
+	MulO,
+	NegO,
+	JumpTab,
+	MemCpy,
+	MemSet,
+	MemCmp,
+	New,
+	GC,
+	EPrint,
+	Sys,
+	GetCR,
+	SetCR,
+	IRet,
+	SysEnter,
+	Spawn,
+	Cas,
+	FetchAdd,
+	Fence,
+	Assert,
 }
 
 var Mnemonics = map[byte]string{
-	Nop:   "NOP",
-	Push:  "PUSH",
-	Pop:   "POP",
-	Call:  "CALL",
-	Ret:   "RET",
-	Jump:  "JUMP",
-	Jz:    "JZ",
-	Halt:  "HALT",
-	Add:   "ADD",
-	Sub:   "SUB",
-	Mul:   "MUL",
-	Div:   "DIV",
-	Mod:   "MOD",
-	Neg:   "NEG",
-	And:   "AND",
-	Or:    "OR",
-	Not:   "NOT",
-	Eq:    "EQ",
-	Ne:    "NE",
-	Lt:    "LT",
-	Le:    "LE",
-	Gt:    "GT",
-	Ge:    "GE",
-	Input: "INPUT",
-	Print: "PRINT",
+	Nop:      "NOP",
+	Push:     "PUSH",
+	Pop:      "POP",
+	Call:     "CALL",
+	Ret:      "RET",
+	Jump:     "JUMP",
+	Jz:       "JZ",
+	Halt:     "HALT",
+	Add:      "ADD",
+	Sub:      "SUB",
+	Mul:      "MUL",
+	Div:      "DIV",
+	Mod:      "MOD",
+	Neg:      "NEG",
+	And:      "AND",
+	Or:       "OR",
+	Not:      "NOT",
+	Eq:       "EQ",
+	Ne:       "NE",
+	Lt:       "LT",
+	Le:       "LE",
+	Gt:       "GT",
+	Ge:       "GE",
+	Input:    "INPUT",
+	Print:    "PRINT",
+	Load:     "LOAD",
+	Store:    "STORE",
+	LoadB:    "LOADB",
+	StoreB:   "STOREB",
+	Enter:    "ENTER",
+	Leave:    "LEAVE",
+	Try:      "TRY",
+	EndTry:   "ENDTRY",
+	Throw:    "THROW",
+	TailCall: "TAILCALL",
+	AddO:     "ADDO",
+	SubO:     "SUBO",
+	MulO:     "MULO",
+	NegO:     "NEGO",
+	JumpTab:  "JUMPTAB",
+	MemCpy:   "MEMCPY",
+	MemSet:   "MEMSET",
+	MemCmp:   "MEMCMP",
+	New:      "NEW",
+	GC:       "GC",
+	EPrint:   "EPRINT",
+	Sys:      "SYS",
+	GetCR:    "GETCR",
+	SetCR:    "SETCR",
+	IRet:     "IRET",
+	SysEnter: "SYSENTER",
+	Spawn:    "SPAWN",
+	Cas:      "CAS",
+	FetchAdd: "FETCHADD",
+	Fence:    "FENCE",
+	Assert:   "ASSERT",
 }
 
 const (
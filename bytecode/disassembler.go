@@ -0,0 +1,218 @@
+package bytecode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+var registerNames = map[uint16]string{
+	InstructionPointer: "IP",
+	StackPointer:       "SP",
+	FramePointer:       "FP",
+}
+
+// հրամաններ, որոնց արգումենտը պիտակի բացարձակ հասցե է
+var labelled = []byte{Call, Jump, Jz, Try, JumpTab, Spawn}
+
+// կարդալ address հասցեում գրված հրամանը
+func decode(code []byte, address int) (*instruction, error) {
+	instr := &instruction{address: address, opcode: code[address]}
+	if instr.opcode&0xC0 == 0xC0 {
+		return nil, fmt.Errorf("%04x: հրամանի սխալ տեսակ %02x", address, instr.opcode)
+	}
+	if _, known := Mnemonics[instr.opcode&0x3F]; !known {
+		return nil, fmt.Errorf("%04x: անծանոթ գործողության կոդ %02x", address, instr.opcode)
+	}
+	if address+instr.size() > len(code) {
+		return nil, fmt.Errorf("%04x: հրամանն ավարտված չէ", address)
+	}
+	switch instr.opcode & 0xC0 {
+	case Immediate:
+		instr.immediate = int32(binary.LittleEndian.Uint32(code[address+1:]))
+	case Indirect:
+		instr.indirect = binary.LittleEndian.Uint16(code[address+1:])
+	}
+	return instr, nil
+}
+
+// կարդալ address հասցեում գրված անցումների աղյուսակը
+func decodeTable(code []byte, address int) (*instruction, error) {
+	if address < 0 || address+2 > len(code) {
+		return nil, fmt.Errorf("%04x: անցումների աղյուսակը դուրս է կոդից", address)
+	}
+	count := int(binary.LittleEndian.Uint16(code[address:]))
+	instr := &instruction{address: address, table: make([]uint16, 1+count)}
+	if address+instr.size() > len(code) {
+		return nil, fmt.Errorf("%04x: անցումների աղյուսակն ավարտված չէ", address)
+	}
+	for k := range instr.table {
+		instr.table[k] = binary.LittleEndian.Uint16(code[address+2+2*k:])
+	}
+	return instr, nil
+}
+
+// բայթկոդը տրոհել հրամանների ու անցումների աղյուսակների. աղյուսակների
+// հասցեները հայտնի են դառնում JUMPTAB հրամաններից, այդ պատճառով
+// տրոհումը կրկնվում է, քանի դեռ նոր աղյուսակներ են հայտնաբերվում
+func split(code []byte) ([]*instruction, error) {
+	tables := map[int]bool{}
+	for {
+		var result []*instruction
+		found := false
+		for address := 0; address < len(code); {
+			var instr *instruction
+			var err error
+			if tables[address] {
+				instr, err = decodeTable(code, address)
+			} else {
+				instr, err = decode(code, address)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if instr.opcode == JumpTab|Indirect && !tables[int(instr.indirect)] {
+				tables[int(instr.indirect)] = true
+				found = true
+			}
+			result = append(result, instr)
+			address += instr.size()
+		}
+		if !found {
+			return result, nil
+		}
+	}
+}
+
+// Disassemble-ը բայթկոդը վերածում է ասեմբլերի լեզվով տեքստի, որը
+// կարելի է նորից ասեմբլացնել։ Անցումների հասցեները ներկայացվում են
+// symbols-ի պիտակներով, իսկ դրանցում չեղած հասցեների համար
+// ստեղծվում են Lxxxx տեսքի պիտակներ
+func Disassemble(writer io.Writer, code []byte, symbols map[string]int) error {
+	instructions, names, err := listing(code, symbols)
+	if err != nil {
+		return err
+	}
+	label := func(address uint16) string {
+		return names[int(address)][0]
+	}
+
+	for _, instr := range instructions {
+		for _, name := range names[instr.address] {
+			fmt.Fprintf(writer, "%s:\n", name)
+		}
+		fmt.Fprintf(writer, "  %-32s; %04x\n", instr.text(label), instr.address)
+	}
+	for _, name := range names[len(code)] {
+		fmt.Fprintf(writer, "%s:\n", name)
+	}
+	return nil
+}
+
+// DisassembleAround-ը Disassemble-ի նման է, բայց արտածում է միայն
+// address հասցեի հրամանը, դրանից առաջ և հետո count-ական հրամաններ։
+// address-ի հրամանը նշվում է => սլաքով, իսկ բացասական count-ը սխալ է
+func DisassembleAround(writer io.Writer, code []byte, symbols map[string]int, address int, count int) error {
+	if count < 0 {
+		return fmt.Errorf("Հրամանների քանակը բացասական է. %d", count)
+	}
+	instructions, names, err := listing(code, symbols)
+	if err != nil {
+		return err
+	}
+	label := func(address uint16) string {
+		return names[int(address)][0]
+	}
+
+	current := slices.IndexFunc(instructions, func(instr *instruction) bool {
+		return instr.address == address
+	})
+	if current == -1 {
+		return fmt.Errorf("%04x: հասցեում հրաման չկա", address)
+	}
+	for _, instr := range instructions[max(0, current-count):min(len(instructions), current+count+1)] {
+		for _, name := range names[instr.address] {
+			fmt.Fprintf(writer, "%s:\n", name)
+		}
+		marker := " "
+		if instr.address == address {
+			marker = "=>"
+		}
+		fmt.Fprintf(writer, "%-2s%-32s; %04x\n", marker, instr.text(label), instr.address)
+	}
+	return nil
+}
+
+// բայթկոդի հրամաններն ու պիտակներն ըստ հասցեների
+func listing(code []byte, symbols map[string]int) ([]*instruction, map[int][]string, error) {
+	instructions, err := split(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// պիտակներն ըստ հասցեների
+	names := map[int][]string{}
+	for name, address := range symbols {
+		names[address] = append(names[address], name)
+	}
+	target := func(address uint16) {
+		if _, exists := names[int(address)]; !exists {
+			names[int(address)] = []string{fmt.Sprintf("L%04x", address)}
+		}
+	}
+	for _, instr := range instructions {
+		switch {
+		case instr.table != nil:
+			for _, address := range instr.table {
+				target(address)
+			}
+		case instr.opcode&0xC0 == Indirect && slices.Contains(labelled, instr.opcode&0x3F):
+			target(instr.indirect)
+		case instr.opcode == TailCall|Immediate:
+			target(uint16(instr.immediate))
+		}
+	}
+	for _, labels := range names {
+		slices.Sort(labels)
+	}
+	return instructions, names, nil
+}
+
+// հրամանի տեքստը ասեմբլերի լեզվով
+func (i *instruction) text(label func(uint16) string) string {
+	if i.table != nil {
+		labels := make([]string, len(i.table))
+		for k, address := range i.table {
+			labels[k] = label(address)
+		}
+		return ".jumptable " + strings.Join(labels, ", ")
+	}
+
+	opcode := i.opcode & 0x3F
+	name := Mnemonics[opcode]
+	switch i.opcode & 0xC0 {
+	case Immediate:
+		if opcode == TailCall {
+			operand := uint32(i.immediate)
+			return fmt.Sprintf("%s %s, %d, %d", name, label(uint16(operand)),
+				operand>>16&0xFF, operand>>24)
+		}
+		return fmt.Sprintf("%s %d", name, i.immediate)
+	case Indirect:
+		if slices.Contains(labelled, opcode) {
+			return fmt.Sprintf("%s %s", name, label(i.indirect))
+		}
+		displacement := int16(i.indirect<<2) >> 2
+		register, known := registerNames[i.indirect&0xC000]
+		if !known {
+			return fmt.Sprintf("%s [%d]", name, displacement)
+		}
+		if displacement < 0 {
+			return fmt.Sprintf("%s [%s - %d]", name, register, -displacement)
+		}
+		return fmt.Sprintf("%s [%s + %d]", name, register, displacement)
+	}
+	return name
+}
@@ -0,0 +1,102 @@
+package bytecode
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	builder := NewBuilder()
+	builder.AddWithLabel(Call, "main")
+	builder.AddBasic(Halt)
+	builder.SetLabel("main")
+	builder.AddWithNumeric(Enter, 1)
+	builder.AddWithAddress(Push, FramePointer, -12)
+	builder.AddWithLabel(JumpTab, "cases")
+	builder.AddWithAddress(Pop, StackPointer, 4)
+	builder.AddTailCall("main", 1, 1)
+	builder.AddWithNumeric(Ret, 1)
+	builder.SetLabel("cases")
+	builder.AddJumpTable("main", "main")
+	builder.Validate()
+
+	buffer := bytes.NewBufferString("")
+	err := Disassemble(buffer, builder.Bytes(), builder.Symbols())
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	expected := "  CALL main                       ; 0000\n" +
+		"  HALT                            ; 0003\n" +
+		"main:\n" +
+		"  ENTER 1                         ; 0004\n" +
+		"  PUSH [FP - 12]                  ; 0009\n" +
+		"  JUMPTAB cases                   ; 000c\n" +
+		"  POP [SP + 4]                    ; 000f\n" +
+		"  TAILCALL main, 1, 1             ; 0012\n" +
+		"  RET 1                           ; 0017\n" +
+		"cases:\n" +
+		"  .jumptable main, main           ; 001c\n"
+	if generated := buffer.String(); expected != generated {
+		t.Errorf("Սպասվում էր\n%s\nստացվել է\n%s", expected, generated)
+	}
+}
+
+func TestDisassembleUnnamedTargets(t *testing.T) {
+	builder := NewBuilder()
+	builder.AddWithLabel(Jump, "end")
+	builder.AddBasic(Nop)
+	builder.SetLabel("end")
+	builder.Validate()
+
+	buffer := bytes.NewBufferString("")
+	if err := Disassemble(buffer, builder.Bytes(), nil); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	expected := "  JUMP L0004                      ; 0000\n" +
+		"  NOP                             ; 0003\n" +
+		"L0004:\n"
+	if generated := buffer.String(); expected != generated {
+		t.Errorf("Սպասվում էր\n%s\nստացվել է\n%s", expected, generated)
+	}
+}
+
+func TestDisassembleInvalidCode(t *testing.T) {
+	for _, code := range [][]byte{{0x3f}, {0xc1}, {0x41, 0x01}} {
+		if err := Disassemble(bytes.NewBufferString(""), code, nil); err == nil {
+			t.Errorf("%v կոդի համար սպասվում է սխալ", code)
+		}
+	}
+}
+
+func TestDisassembleAround(t *testing.T) {
+	builder := NewBuilder()
+	builder.AddWithLabel(Call, "main")
+	builder.AddBasic(Halt)
+	builder.SetLabel("main")
+	builder.AddWithNumeric(Push, 1)
+	builder.AddWithNumeric(Push, 0)
+	builder.AddBasic(Div)
+	builder.AddWithNumeric(Ret, 0)
+	builder.Validate()
+
+	buffer := bytes.NewBufferString("")
+	if err := DisassembleAround(buffer, builder.Bytes(), builder.Symbols(), 14, 1); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	expected := "  PUSH 0                          ; 0009\n" +
+		"=>DIV                             ; 000e\n" +
+		"  RET 0                           ; 000f\n"
+	if generated := buffer.String(); expected != generated {
+		t.Errorf("Սպասվում էր\n%s\nստացվել է\n%s", expected, generated)
+	}
+
+	if err := DisassembleAround(buffer, builder.Bytes(), nil, 13, 1); err == nil {
+		t.Errorf("Սպասվում է սխալ")
+	}
+	if err := DisassembleAround(buffer, builder.Bytes(), nil, 14, -3); err == nil {
+		t.Errorf("Բացասական քանակի համար սպասվում է սխալ")
+	}
+}
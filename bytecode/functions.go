@@ -0,0 +1,61 @@
+package bytecode
+
+import (
+	"maps"
+	"slices"
+)
+
+// ենթածրագիր
+type Function struct {
+	Address   int // սկզբի հասցեն
+	Arguments int // արգումենտների քանակը, -1՝ եթե այն հայտնի չէ
+}
+
+// Functions-ը գտնում է բայթկոդի ենթածրագրերը՝ CALL, TAILCALL և SPAWN
+// հրամանների անցման հասցեները, ինչպես նաև ծրագրի սկիզբը՝ 0 հասցեն։
+// Ենթածրագրի արգումենտների քանակը որոշվում է դրա սկզբից հետո առաջին
+// RET n կամ TAILCALL հրամանով։ Ենթածրագրերը վերադարձվում են ըստ հասցեների
+func Functions(code []byte) ([]Function, error) {
+	instructions, err := split(code)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[int]bool{0: true}
+	for _, instr := range instructions {
+		switch instr.opcode {
+		case Call | Indirect, Spawn | Indirect:
+			entries[int(instr.indirect)] = true
+		case TailCall | Immediate:
+			entries[int(uint16(instr.immediate))] = true
+		}
+	}
+
+	functions := []Function{}
+	for _, address := range slices.Sorted(maps.Keys(entries)) {
+		function := Function{Address: address}
+		if address != 0 {
+			function.Arguments = arguments(instructions, address)
+		}
+		functions = append(functions, function)
+	}
+	return functions, nil
+}
+
+// address հասցեից սկսվող ենթածրագրի արգումենտների քանակը
+func arguments(instructions []*instruction, address int) int {
+	for _, instr := range instructions {
+		if instr.address < address {
+			continue
+		}
+		switch instr.opcode {
+		case Ret | Basic:
+			return 0
+		case Ret | Immediate:
+			return int(instr.immediate)
+		case TailCall | Immediate:
+			return int(uint32(instr.immediate) >> 24)
+		}
+	}
+	return -1
+}
@@ -0,0 +1,29 @@
+package bytecode
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	builder := NewBuilder()
+	builder.AddWithLabel(Call, "first")
+	builder.AddBasic(Halt)
+	builder.SetLabel("first")
+	builder.AddTailCall("second", 1, 2)
+	builder.SetLabel("second")
+	builder.AddWithNumeric(Ret, 1)
+	builder.AddWithLabel(Spawn, "worker")
+	builder.SetLabel("worker")
+	builder.AddBasic(Halt)
+	builder.Validate()
+
+	functions, err := Functions(builder.Bytes())
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	expected := []Function{{0, 0}, {4, 2}, {9, 1}, {17, -1}}
+	if !slices.Equal(functions, expected) {
+		t.Errorf("Սպասվում էր %v, ստացվել է %v", expected, functions)
+	}
+}
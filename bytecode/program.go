@@ -0,0 +1,8 @@
+package bytecode
+
+// ասեմբլացված ծրագիրը
+type Program struct {
+	Code    []byte         // բայթկոդը
+	Symbols map[string]int // պիտակների հասցեները
+	Lines   map[int]int    // ամեն հրամանի հասցեին համապատասխանող տեքստի տողը
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"svm/bytecode"
+	"svm/machine"
+)
+
+// ուսումնասիրել file core ֆայլը. args-ը ուսումնասիրման հրամանն է՝
+// bt, dis [n], mem հասցե [քանակ] կամ frame n
+func inspect(file string, args []string) int {
+	input, err := os.Open(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Չհաջողվեց բացել core ֆայլը. %s\n", file)
+		return exitFailure
+	}
+	core, err := machine.ReadCore(input)
+	input.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+
+	command := "bt"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	numbers := make([]int, len(args))
+	for k, arg := range args {
+		number, err := strconv.ParseInt(arg, 0, 32)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Սպասվում է թիվ, բայց ստացվել է %s\n", arg)
+			return exitFailure
+		}
+		numbers[k] = int(number)
+	}
+	argument := func(k int, fallback int) int {
+		if k < len(numbers) {
+			return numbers[k]
+		}
+		return fallback
+	}
+
+	vm := core.Machine()
+	switch command {
+	case "bt":
+		fmt.Println(core.Trap)
+		r := core.Registers
+		fmt.Printf("IP=%04x SP=%04x FP=%04x HP=%d, %s\n", r.IP, r.SP, r.FP, core.HP, r.Mode)
+		for k, frame := range vm.Backtrace() {
+			fmt.Printf("  #%d %s\n", k, frame)
+		}
+	case "dis":
+		code := core.Program.Code
+		if len(core.Code) > 0 {
+			code = core.Code
+		}
+		count := argument(0, 5)
+		if count < 0 {
+			fmt.Fprintf(os.Stderr, "Սխալ քանակ. %d\n", count)
+			return exitFailure
+		}
+		err = bytecode.DisassembleAround(os.Stdout, code, core.Program.Symbols,
+			int(core.Registers.IP), count)
+	case "mem":
+		if len(numbers) == 0 {
+			fmt.Fprintln(os.Stderr, "Նշված չէ հասցեն։")
+			return exitFailure
+		}
+		count := argument(1, 64)
+		if count < 0 {
+			fmt.Fprintf(os.Stderr, "Սխալ քանակ. %d\n", count)
+			return exitFailure
+		}
+		err = dump(vm, int32(numbers[0]), count)
+	case "frame":
+		frames := vm.Backtrace()
+		k := argument(0, 0)
+		if k < 0 || k >= len(frames) {
+			fmt.Fprintf(os.Stderr, "Կադր %d գոյություն չունի։\n", k)
+			return exitFailure
+		}
+		err = showFrame(vm, core, frames, k)
+	default:
+		fmt.Fprintf(os.Stderr, "Անծանոթ հրաման %s։\n", command)
+		return exitFailure
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+	return 0
+}
+
+// արտածել address հասցեից սկսվող count բայթերը՝ 16-ական տողերով
+func dump(vm *machine.Machine, address int32, count int) error {
+	bytes, err := vm.ReadMemory(address, count)
+	if err != nil {
+		return err
+	}
+	for k := 0; k < len(bytes); k += 16 {
+		fmt.Printf("%04x:", address+int32(k))
+		for _, b := range bytes[k:min(k+16, len(bytes))] {
+			fmt.Printf(" %02x", b)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// արտածել frames-ի k-րդ կադրի բառերը՝ արգումենտներից մինչև կադրի գագաթը
+func showFrame(vm *machine.Machine, core *machine.Core, frames []machine.Frame, k int) error {
+	frame := frames[k]
+	fmt.Printf("#%d %s\n", k, frame)
+
+	// կադրի սկիզբը, իսկ ամենաարտաքին կադրի համար՝ ստեկի սկիզբը
+	start := frame.FP - 8 - 4*int16(len(frame.Arguments))
+	outermost := k == len(frames)-1 && frame.FP < core.Base+8
+	if outermost {
+		start = core.Base
+	}
+	// կադրի գագաթը՝ ներքին կադրի սկիզբը
+	top := core.Registers.SP
+	if k > 0 {
+		inner := frames[k-1]
+		top = inner.FP - 8 - 4*int16(len(inner.Arguments))
+	}
+
+	for address := start; address+4 <= top; address += 4 {
+		bytes, err := vm.ReadMemory(int32(address), 4)
+		if err != nil {
+			return err
+		}
+		value := int32(binary.LittleEndian.Uint32(bytes))
+		if outermost {
+			fmt.Printf("  %04x: %d\n", address, value)
+			continue
+		}
+		note := ""
+		switch address - frame.FP {
+		case -8:
+			note = "  ; վերադարձի հասցեն"
+		case -4:
+			note = "  ; կանչողի FP-ն"
+		}
+		fmt.Printf("  [FP %+4d] %04x: %d%s\n", address-frame.FP, address, value, note)
+	}
+	return nil
+}
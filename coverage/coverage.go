@@ -0,0 +1,200 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"svm/bytecode"
+	"svm/machine"
+)
+
+// Ծածկույթը հաշվվում է ծրագրի տեքստի տողերով։ Ամեն հրաման պարունակող
+// տողի համար հաշվվում է դրա կատարումների քանակը, իսկ JZ պարունակող
+// տողերի համար՝ նաև թե քանի անգամ է անցումը կատարվել (պայմանը 0 է),
+// և քանի անգամ է կատարումը շարունակվել հաջորդ հրամանից։ Նույն ծրագրի
+// տարբեր կատարումների ծածկույթները կարելի է միավորել Merge-ով։
+
+// JZ հրամանի ճյուղերի կատարումների քանակները
+type Branch struct {
+	Taken    int // անցումը կատարվել է
+	NotTaken int // կատարումը շարունակվել է հաջորդ հրամանից
+}
+
+// ծրագրի ծածկույթը
+type Profile struct {
+	File     string          // ծրագրի տեքստի ֆայլը
+	Lines    map[int]int     // հրաման պարունակող տողերի կատարումների քանակը
+	Branches map[int]*Branch // JZ պարունակող տողերի ճյուղերը
+}
+
+// program ծրագրի դատարկ ծածկույթը, file-ը ծրագրի տեքստի ֆայլն է
+func NewProfile(file string, program *bytecode.Program) *Profile {
+	p := &Profile{File: file, Lines: map[int]int{}, Branches: map[int]*Branch{}}
+	for address, line := range program.Lines {
+		p.Lines[line] += 0
+		if isJz(program.Code, address) {
+			p.Branches[line] = &Branch{}
+		}
+	}
+	return p
+}
+
+func isJz(code []byte, address int) bool {
+	return address+3 <= len(code) && code[address] == bytecode.Jz|bytecode.Indirect
+}
+
+// ավելացնել other ծածկույթը
+func (p *Profile) Merge(other *Profile) error {
+	if other.File != p.File {
+		return fmt.Errorf("Ծածկույթները տարբեր ծրագրերի են. %s և %s", p.File, other.File)
+	}
+	for line, count := range other.Lines {
+		p.Lines[line] += count
+	}
+	for line, branch := range other.Branches {
+		if p.Branches[line] == nil {
+			p.Branches[line] = &Branch{}
+		}
+		p.Branches[line].Taken += branch.Taken
+		p.Branches[line].NotTaken += branch.NotTaken
+	}
+	return nil
+}
+
+// ծածկույթի ամփոփումը
+type Summary struct {
+	Lines, CoveredLines       int // հրաման պարունակող և կատարված տողերը
+	Branches, CoveredBranches int // ճյուղերը և կատարված ճյուղերը
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("Տողեր՝ %d/%d (%.1f%%), ճյուղեր՝ %d/%d (%.1f%%)",
+		s.CoveredLines, s.Lines, percent(s.CoveredLines, s.Lines),
+		s.CoveredBranches, s.Branches, percent(s.CoveredBranches, s.Branches))
+}
+
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 100
+	}
+	return 100 * float64(part) / float64(whole)
+}
+
+func (p *Profile) Summary() Summary {
+	s := Summary{Lines: len(p.Lines), Branches: 2 * len(p.Branches)}
+	for _, count := range p.Lines {
+		if count > 0 {
+			s.CoveredLines++
+		}
+	}
+	for _, branch := range p.Branches {
+		s.CoveredBranches += branch.covered()
+	}
+	return s
+}
+
+// կատարված ճյուղերի քանակը
+func (b *Branch) covered() int {
+	covered := 0
+	if b.Taken > 0 {
+		covered++
+	}
+	if b.NotTaken > 0 {
+		covered++
+	}
+	return covered
+}
+
+// Ծածկույթը պահվում է տեքստային ֆայլում, որի առաջին տողը ծրագրի
+// ֆայլն է, իսկ հաջորդները՝ «line տող քանակ» և «branch տող անցում
+// շարունակում» տեսքի տողեր։
+const header = "svm coverage: "
+
+// գրել ծածկույթը writer-ում
+func (p *Profile) Write(writer io.Writer) error {
+	w := bufio.NewWriter(writer)
+	fmt.Fprintf(w, "%s%s\n", header, p.File)
+	for _, line := range slices.Sorted(maps.Keys(p.Lines)) {
+		fmt.Fprintf(w, "line %d %d\n", line, p.Lines[line])
+	}
+	for _, line := range slices.Sorted(maps.Keys(p.Branches)) {
+		branch := p.Branches[line]
+		fmt.Fprintf(w, "branch %d %d %d\n", line, branch.Taken, branch.NotTaken)
+	}
+	return w.Flush()
+}
+
+// կարդալ Write-ով գրված ծածկույթը
+func ReadProfile(reader io.Reader) (*Profile, error) {
+	sc := bufio.NewScanner(reader)
+	if !sc.Scan() || len(sc.Text()) < len(header) || sc.Text()[:len(header)] != header {
+		return nil, fmt.Errorf("Ծածկույթի ֆայլի սխալ վերնագիր")
+	}
+	p := &Profile{File: sc.Text()[len(header):], Lines: map[int]int{}, Branches: map[int]*Branch{}}
+	for number := 2; sc.Scan(); number++ {
+		var line, count int
+		branch := &Branch{}
+		if _, err := fmt.Sscanf(sc.Text(), "line %d %d", &line, &count); err == nil {
+			p.Lines[line] = count
+		} else if _, err := fmt.Sscanf(sc.Text(), "branch %d %d %d", &line, &branch.Taken, &branch.NotTaken); err == nil {
+			p.Branches[line] = branch
+		} else {
+			return nil, fmt.Errorf("Ծածկույթի ֆայլի %d տողը սխալ է. %s", number, sc.Text())
+		}
+	}
+	return p, sc.Err()
+}
+
+// Recorder-ը դիտորդ է, որը կատարված հրամաններն ավելացնում է
+// ծածկույթում։ JZ-ի ճյուղը որոշվում է նույն հոսքի հաջորդ հրամանի
+// հասցեով։ Նույն Recorder-ը կարելի է տեղադրել մի քանի մեքենաներում
+// հաջորդաբար, և դրանց ծածկույթները կգումարվեն։
+type Recorder struct {
+	machine.NopObserver
+	profile *Profile
+	lines   map[int16]int   // հրամանի հասցեի տողը
+	targets map[int16]int16 // JZ հրամանների անցման հասցեները
+	pending map[int]int16   // ամեն հոսքի վերջին JZ-ի հասցեն, որի ճյուղը դեռ հայտնի չէ
+}
+
+// program ծրագրի կատարումը p ծածկույթում գրանցող դիտորդ
+func (p *Profile) Recorder(program *bytecode.Program) *Recorder {
+	r := &Recorder{
+		profile: p,
+		lines:   map[int16]int{},
+		targets: map[int16]int16{},
+		pending: map[int]int16{},
+	}
+	for address, line := range program.Lines {
+		r.lines[int16(address)] = line
+		if isJz(program.Code, address) {
+			r.targets[int16(address)] = int16(uint16(program.Code[address+1]) | uint16(program.Code[address+2])<<8)
+		}
+	}
+	return r
+}
+
+func (r *Recorder) Fetch(registers machine.Registers, command byte) {
+	if jz, ok := r.pending[registers.Thread]; ok {
+		delete(r.pending, registers.Thread)
+		if branch := r.profile.Branches[r.lines[jz]]; branch != nil {
+			switch registers.IP {
+			case r.targets[jz]:
+				branch.Taken++
+			case jz + 3:
+				branch.NotTaken++
+			}
+		}
+	}
+
+	line, ok := r.lines[registers.IP]
+	if !ok {
+		return
+	}
+	r.profile.Lines[line]++
+	if _, ok := r.targets[registers.IP]; ok {
+		r.pending[registers.Thread] = registers.IP
+	}
+}
@@ -0,0 +1,137 @@
+package coverage
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"svm/assembler"
+	"svm/bytecode"
+	"svm/machine"
+	"testing"
+)
+
+const example = `; երկու թվերից մեծը
+  INPUT
+  INPUT
+  LT
+  JZ first
+  PUSH 2
+  PRINT
+  HALT
+first:
+  PUSH 1
+  PRINT
+  HALT
+`
+
+func assemble(t *testing.T) (string, *bytecode.Program) {
+	file := filepath.Join(t.TempDir(), "example.asm")
+	if err := os.WriteFile(file, []byte(example), 0o644); err != nil {
+		t.Fatalf("Չկարողացա ստեղծել ֆայլը։ (%v)", err)
+	}
+	program, err := assembler.AssembleProgram(file)
+	if err != nil {
+		t.Fatalf("Ասեմբլերի սխալ։ (%v)", err)
+	}
+	return file, program
+}
+
+// կատարել ծրագիրը input ներածմամբ՝ ծածկույթը գրանցելով profile-ում
+func run(t *testing.T, program *bytecode.Program, profile *Profile, input string) {
+	m := machine.NewMachine(
+		machine.WithStdin(strings.NewReader(input)),
+		machine.WithStdout(io.Discard),
+		machine.WithObserver(profile.Recorder(program)))
+	m.Load(program.Code)
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	file, program := assemble(t)
+
+	profile := NewProfile(file, program)
+	run(t, program, profile, "3 5")
+	if s := profile.Summary(); s.CoveredLines != 7 || s.CoveredBranches != 1 {
+		t.Errorf("Սխալ ամփոփում. %v", s)
+	}
+	if profile.Lines[10] != 0 || profile.Lines[6] != 1 {
+		t.Errorf("Սխալ տողեր. %v", profile.Lines)
+	}
+
+	// երկրորդ կատարումը ծածկում է մյուս ճյուղը
+	second := NewProfile(file, program)
+	run(t, program, second, "5 3")
+	if err := profile.Merge(second); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	expected := Summary{Lines: 10, CoveredLines: 10, Branches: 2, CoveredBranches: 2}
+	if s := profile.Summary(); s != expected {
+		t.Errorf("Սպասվում է %v, բայց ստացվել է %v", expected, s)
+	}
+	if branch := profile.Branches[5]; branch == nil || branch.Taken != 1 || branch.NotTaken != 1 {
+		t.Errorf("Սխալ ճյուղեր. %v", branch)
+	}
+	if profile.Lines[2] != 2 {
+		t.Errorf("Սպասվում է 2, բայց ստացվել է %d", profile.Lines[2])
+	}
+}
+
+func TestProfileReadWrite(t *testing.T) {
+	file, program := assemble(t)
+	profile := NewProfile(file, program)
+	run(t, program, profile, "1 2")
+
+	var buffer bytes.Buffer
+	if err := profile.Write(&buffer); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	read, err := ReadProfile(&buffer)
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if read.File != file || read.Summary() != profile.Summary() || *read.Branches[5] != *profile.Branches[5] {
+		t.Errorf("Սպասվում է %+v, բայց ստացվել է %+v", profile, read)
+	}
+
+	if _, err := ReadProfile(strings.NewReader("line 1 2\n")); err == nil {
+		t.Errorf("Սպասվում է սխալ")
+	}
+}
+
+func TestReports(t *testing.T) {
+	file, program := assemble(t)
+	profile := NewProfile(file, program)
+	run(t, program, profile, "1 2")
+
+	var text bytes.Buffer
+	profile.WriteText(&text, example)
+	for _, expected := range []string{
+		"     1 │    5 │   JZ first  [անցում՝ 0, շարունակում՝ 1]",
+		"#####",
+		"Տողեր՝ 7/10 (70.0%), ճյուղեր՝ 1/2 (50.0%)",
+	} {
+		if !strings.Contains(text.String(), expected) {
+			t.Errorf("Տեքստային հաշվետվությունում սպասվում է %q\n%s", expected, text.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := profile.WriteHTML(&html, example); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if !strings.Contains(html.String(), `<tr class="missed">`) || !strings.Contains(html.String(), `<tr class="partial">`) {
+		t.Errorf("Սխալ HTML հաշվետվություն\n%s", html.String())
+	}
+
+	var lcov bytes.Buffer
+	profile.WriteLCOV(&lcov)
+	for _, expected := range []string{"SF:" + file, "DA:10,0", "BRDA:5,0,0,0", "BRDA:5,0,1,1", "BRH:1", "LH:7", "end_of_record"} {
+		if !strings.Contains(lcov.String(), expected+"\n") {
+			t.Errorf("LCOV հաշվետվությունում սպասվում է %q\n%s", expected, lcov.String())
+		}
+	}
+}
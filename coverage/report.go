@@ -0,0 +1,146 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ծրագրի տեքստի տողի վիճակը հաշվետվությունում
+type status int
+
+const (
+	statusNone    status = iota // տողում հրաման չկա
+	statusMissed                // հրամանը չի կատարվել
+	statusPartial               // JZ-ի ճյուղերից մեկը չի կատարվել
+	statusCovered               // հրամանն ու դրա բոլոր ճյուղերը կատարվել են
+)
+
+// հաշվետվության տողը
+type row struct {
+	Number int
+	Source string
+	Status status
+	Count  int
+	Branch *Branch
+}
+
+// source տեքստի տողերը՝ ծածկույթի տվյալներով
+func (p *Profile) rows(source string) []row {
+	rows := []row{}
+	for k, text := range strings.Split(strings.TrimSuffix(source, "\n"), "\n") {
+		r := row{Number: k + 1, Source: strings.TrimRight(text, "\r")}
+		if count, ok := p.Lines[r.Number]; ok {
+			r.Count = count
+			r.Branch = p.Branches[r.Number]
+			switch {
+			case count == 0:
+				r.Status = statusMissed
+			case r.Branch != nil && r.Branch.covered() < 2:
+				r.Status = statusPartial
+			default:
+				r.Status = statusCovered
+			}
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// ճյուղերի նկարագրությունը
+func (b *Branch) String() string {
+	return fmt.Sprintf("անցում՝ %d, շարունակում՝ %d", b.Taken, b.NotTaken)
+}
+
+// գրել source ծրագրի տեքստը writer-ում՝ ամեն տողի սկզբում նշելով
+// կատարումների քանակը կամ ##### նիշերը չկատարված հրամանների համար
+func (p *Profile) WriteText(writer io.Writer, source string) error {
+	w := bufio.NewWriter(writer)
+	for _, r := range p.rows(source) {
+		count := ""
+		switch r.Status {
+		case statusMissed:
+			count = "#####"
+		case statusPartial, statusCovered:
+			count = fmt.Sprint(r.Count)
+		}
+		fmt.Fprintf(w, "%6s │ %4d │ %s", count, r.Number, r.Source)
+		if r.Branch != nil {
+			fmt.Fprintf(w, "  [%s]", r.Branch)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, p.Summary())
+	return w.Flush()
+}
+
+var page = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.File}}</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; }
+td { padding: 0 8px; white-space: pre; }
+td.number, td.count { text-align: right; color: #666; }
+tr.missed { background: #fdd; }
+tr.partial { background: #ffc; }
+tr.covered { background: #dfd; }
+</style>
+</head>
+<body>
+<h1>{{.File}}</h1>
+<p>{{.Summary}}</p>
+<table>
+{{- range .Rows}}
+<tr class="{{.Class}}"><td class="number">{{.Number}}</td><td class="count">{{if .Status}}{{.Count}}{{end}}</td><td>{{.Source}}</td><td>{{with .Branch}}{{.}}{{end}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// HTML-ում տողի դասը
+func (r row) Class() string {
+	return [...]string{"", "missed", "partial", "covered"}[r.Status]
+}
+
+// գրել source ծրագրի տեքստը HTML էջի տեսքով, որում տողերը գունավորված
+// են ըստ ծածկույթի
+func (p *Profile) WriteHTML(writer io.Writer, source string) error {
+	return page.Execute(writer, struct {
+		File    string
+		Summary Summary
+		Rows    []row
+	}{p.File, p.Summary(), p.rows(source)})
+}
+
+// գրել ծածկույթը LCOV ձևաչափով
+func (p *Profile) WriteLCOV(writer io.Writer) error {
+	w := bufio.NewWriter(writer)
+	fmt.Fprintln(w, "TN:")
+	fmt.Fprintf(w, "SF:%s\n", p.File)
+	for _, line := range slices.Sorted(maps.Keys(p.Lines)) {
+		fmt.Fprintf(w, "DA:%d,%d\n", line, p.Lines[line])
+	}
+	for _, line := range slices.Sorted(maps.Keys(p.Branches)) {
+		branch := p.Branches[line]
+		for k, count := range []int{branch.Taken, branch.NotTaken} {
+			taken := "-"
+			if p.Lines[line] > 0 {
+				taken = fmt.Sprint(count)
+			}
+			fmt.Fprintf(w, "BRDA:%d,0,%d,%s\n", line, k, taken)
+		}
+	}
+	s := p.Summary()
+	fmt.Fprintf(w, "BRF:%d\nBRH:%d\n", s.Branches, s.CoveredBranches)
+	fmt.Fprintf(w, "LF:%d\nLH:%d\n", s.Lines, s.CoveredLines)
+	fmt.Fprintln(w, "end_of_record")
+	return w.Flush()
+}
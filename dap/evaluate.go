@@ -0,0 +1,132 @@
+package dap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"svm/machine"
+	"unicode"
+)
+
+// Արտահայտությունները կազմված են թվերից, IP, SP, FP ռեգիստրներից,
+// ծրագրի պիտակներից, + և - գործողություններից, փակագծերից և
+// [հասցե] տեսքի հիշողության բառերից.
+//
+//	expression := term { ('+' | '-') term }
+//	term       := number | register | label | '-' term | '(' expression ')' | '[' expression ']'
+
+type evaluator struct {
+	server    *Server
+	registers machine.Registers
+	tokens    []string
+	position  int
+}
+
+// հաշվել expression-ը registers ռեգիստրներով
+func (s *Server) calculate(expression string, registers machine.Registers) (int32, error) {
+	e := &evaluator{server: s, registers: registers, tokens: tokenize(expression)}
+	if len(e.tokens) == 0 {
+		return 0, fmt.Errorf("Դատարկ արտահայտություն")
+	}
+	value, err := e.expression()
+	if err != nil {
+		return 0, err
+	}
+	if e.position < len(e.tokens) {
+		return 0, fmt.Errorf("Անսպասելի %s", e.tokens[e.position])
+	}
+	return value, nil
+}
+
+// տրոհել արտահայտությունը բառերի և նշանների
+func tokenize(expression string) []string {
+	tokens := []string{}
+	runes := []rune(expression)
+	for k := 0; k < len(runes); {
+		switch r := runes[k]; {
+		case unicode.IsSpace(r):
+			k++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			start := k
+			for k < len(runes) && (unicode.IsLetter(runes[k]) || unicode.IsDigit(runes[k]) || runes[k] == '_') {
+				k++
+			}
+			tokens = append(tokens, string(runes[start:k]))
+		default:
+			tokens = append(tokens, string(r))
+			k++
+		}
+	}
+	return tokens
+}
+
+func (e *evaluator) next() string {
+	if e.position == len(e.tokens) {
+		return ""
+	}
+	e.position++
+	return e.tokens[e.position-1]
+}
+
+func (e *evaluator) peek() string {
+	if e.position == len(e.tokens) {
+		return ""
+	}
+	return e.tokens[e.position]
+}
+
+func (e *evaluator) expression() (int32, error) {
+	value, err := e.term()
+	for err == nil && (e.peek() == "+" || e.peek() == "-") {
+		operation := e.next()
+		var right int32
+		right, err = e.term()
+		if operation == "+" {
+			value += right
+		} else {
+			value -= right
+		}
+	}
+	return value, err
+}
+
+func (e *evaluator) term() (int32, error) {
+	token := e.next()
+	switch token {
+	case "":
+		return 0, fmt.Errorf("Արտահայտությունն ավարտվեց անսպասելիորեն")
+	case "-":
+		value, err := e.term()
+		return -value, err
+	case "(", "[":
+		value, err := e.expression()
+		if err != nil {
+			return 0, err
+		}
+		closing := map[string]string{"(": ")", "[": "]"}[token]
+		if e.next() != closing {
+			return 0, fmt.Errorf("Սպասվում է %s", closing)
+		}
+		if token == "[" {
+			return e.server.word(value)
+		}
+		return value, nil
+	}
+
+	switch strings.ToUpper(token) {
+	case "IP":
+		return int32(e.registers.IP), nil
+	case "SP":
+		return int32(e.registers.SP), nil
+	case "FP":
+		return int32(e.registers.FP), nil
+	}
+	if address, ok := e.server.program.Symbols[token]; ok {
+		return int32(address), nil
+	}
+	value, err := strconv.ParseInt(token, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Անծանոթ անուն %s", token)
+	}
+	return int32(value), nil
+}
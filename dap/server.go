@@ -0,0 +1,556 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"svm/assembler"
+	"svm/bytecode"
+	"svm/machine"
+	"sync"
+	"sync/atomic"
+)
+
+// Server-ը Debug Adapter Protocol-ի սերվեր է, որը կարդում է հարցումները
+// reader-ից և պատասխաններն ու իրադարձությունները գրում է writer-ում։
+// Ծրագիրը կատարվում է առանձին gorutine-ում, որպեսզի կատարման ընթացքում
+// հնարավոր լինի ստանալ pause հարցումը։ Մեքենայի վիճակը (կանչերի ստեկը,
+// ռեգիստրներն ու հիշողությունը) հասանելի է միայն, երբ ծրագիրը կանգնած է։
+type Server struct {
+	reader *bufio.Reader
+	writer io.Writer
+	output sync.Mutex // հաղորդագրությունների գրառումը
+	seq    int
+
+	mutex       sync.Mutex
+	vm          *machine.Machine
+	program     *bytecode.Program
+	path        string          // ծրագրի տեքստի ֆայլը
+	stopOnEntry bool            // կանգնել առաջին հրամանից առաջ
+	breakpoints map[int16]int   // կանգառի կետերի հասցեներն ու տողերը
+	running     bool            // ծրագիրը կատարվում է
+	finished    bool            // ծրագիրն ավարտվել է կամ կանգնել է ծուղակով
+	frames      []machine.Frame // կանչերի ստեկը վերջին կանգառի պահին
+
+	pause atomic.Bool    // կանգնեցնել կատարումը
+	done  sync.WaitGroup // կատարող gorutine-ը
+}
+
+func NewServer(reader io.Reader, writer io.Writer) *Server {
+	return &Server{
+		reader:      bufio.NewReader(reader),
+		writer:      writer,
+		breakpoints: map[int16]int{},
+	}
+}
+
+// մշակել հարցումները, քանի դեռ հաճախորդը չի ուղարկել disconnect կամ
+// չի փակել կապը
+func (s *Server) Serve() error {
+	defer s.close()
+	for {
+		body, err := readMessage(s.reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var r request
+		if err := json.Unmarshal(body, &r); err != nil {
+			return fmt.Errorf("Հաղորդագրության սխալ։ (%v)", err)
+		}
+		if r.Type != "request" {
+			continue
+		}
+		if quit := s.handle(r); quit {
+			return nil
+		}
+	}
+}
+
+// կանգնեցնել կատարումը և փակել մեքենան
+func (s *Server) close() {
+	s.pause.Store(true)
+	s.done.Wait()
+	if s.vm != nil {
+		s.vm.Close()
+	}
+}
+
+// հարցման մշակիչը վերադարձնում է պատասխանի պարունակությունը և
+// պատասխանից հետո կատարվող գործողությունը
+type handler func(s *Server, arguments json.RawMessage) (any, func(), error)
+
+var handlers = map[string]handler{
+	"initialize":        (*Server).initialize,
+	"launch":            (*Server).launch,
+	"setBreakpoints":    (*Server).setBreakpoints,
+	"configurationDone": (*Server).configurationDone,
+	"threads":           (*Server).threads,
+	"stackTrace":        (*Server).stackTrace,
+	"scopes":            (*Server).scopes,
+	"variables":         (*Server).variables,
+	"evaluate":          (*Server).evaluate,
+	"continue":          stepper(stepContinue),
+	"next":              stepper(stepOver),
+	"stepIn":            stepper(stepIn),
+	"stepOut":           stepper(stepOut),
+	"pause":             (*Server).pauseRequest,
+	"terminate":         (*Server).terminate,
+	"disconnect":        (*Server).terminate,
+}
+
+// մշակել r հարցումը. վերադարձնում է true, եթե սերվերը պետք է ավարտվի
+func (s *Server) handle(r request) bool {
+	reply := response{Type: "response", RequestSeq: r.Seq, Command: r.Command, Success: true}
+	var after func()
+	if h, ok := handlers[r.Command]; ok {
+		var err error
+		reply.Body, after, err = h(s, r.Arguments)
+		if err != nil {
+			reply.Success, reply.Message, reply.Body = false, err.Error(), nil
+		}
+	} else {
+		reply.Success, reply.Message = false, fmt.Sprintf("Անծանոթ հրաման %s", r.Command)
+	}
+	s.send(&reply)
+	if after != nil {
+		after()
+	}
+	return r.Command == "disconnect"
+}
+
+// ուղարկել հաղորդագրությունը՝ տալով դրան հերթական համարը
+func (s *Server) send(message any) {
+	s.output.Lock()
+	defer s.output.Unlock()
+	s.seq++
+	switch m := message.(type) {
+	case *response:
+		m.Seq = s.seq
+	case *event:
+		m.Seq = s.seq
+	}
+	writeMessage(s.writer, message)
+}
+
+func (s *Server) sendEvent(name string, body any) {
+	s.send(&event{Type: "event", Event: name, Body: body})
+}
+
+// ծրագրի արտածումը, որն ուղարկվում է output իրադարձություններով
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.server.sendEvent("output", map[string]any{"category": w.category, "output": string(p)})
+	return len(p), nil
+}
+
+func (s *Server) initialize(json.RawMessage) (any, func(), error) {
+	return map[string]any{
+		"supportsConfigurationDoneRequest": true,
+		"supportsEvaluateForHovers":        true,
+		"supportsTerminateRequest":         true,
+	}, nil, nil
+}
+
+// ասեմբլացնել ու բեռնել ծրագիրը. կատարումն սկսվում է configurationDone-ից հետո
+func (s *Server) launch(raw json.RawMessage) (any, func(), error) {
+	var arguments launchArguments
+	if err := json.Unmarshal(raw, &arguments); err != nil {
+		return nil, nil, err
+	}
+	program, err := assembler.AssembleProgram(arguments.Program)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vm := machine.NewMachine(
+		machine.WithStdin(strings.NewReader(arguments.Input)),
+		machine.WithStdout(outputWriter{s, "stdout"}),
+		machine.WithStderr(outputWriter{s, "stderr"}))
+	vm.LoadProgram(program)
+	if err := vm.SetArguments(arguments.Args); err != nil {
+		return nil, nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.vm, s.program, s.path = vm, program, arguments.Program
+	s.stopOnEntry = arguments.StopOnEntry
+	s.frames = vm.Backtrace()
+	return nil, func() { s.sendEvent("initialized", nil) }, nil
+}
+
+// տեղադրել ծրագրի կանգառի կետերը. հրաման չպարունակող տողի կետը
+// տեղափոխվում է հաջորդ հրամանի տողին
+func (s *Server) setBreakpoints(raw json.RawMessage) (any, func(), error) {
+	var arguments setBreakpointsArguments
+	if err := json.Unmarshal(raw, &arguments); err != nil {
+		return nil, nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.program == nil {
+		return nil, nil, fmt.Errorf("Ծրագիրը բեռնված չէ")
+	}
+	if !samePath(arguments.Source.Path, s.path) {
+		result := make([]breakpoint, len(arguments.Breakpoints))
+		for k := range result {
+			result[k].Message = "Ֆայլը կատարվող ծրագիրը չէ"
+		}
+		return map[string]any{"breakpoints": result}, nil, nil
+	}
+
+	// ամեն տողի առաջին հրամանի հասցեն
+	addresses := map[int]int16{}
+	for address, line := range s.program.Lines {
+		if current, exists := addresses[line]; !exists || int16(address) < current {
+			addresses[line] = int16(address)
+		}
+	}
+	lines := slices.Sorted(maps.Keys(addresses))
+
+	clear(s.breakpoints)
+	result := []breakpoint{}
+	for _, b := range arguments.Breakpoints {
+		k, _ := slices.BinarySearch(lines, b.Line)
+		if k == len(lines) {
+			result = append(result, breakpoint{Line: b.Line, Message: "Տողից հետո հրամաններ չկան"})
+			continue
+		}
+		s.breakpoints[addresses[lines[k]]] = lines[k]
+		result = append(result, breakpoint{Verified: true, Line: lines[k]})
+	}
+	return map[string]any{"breakpoints": result}, nil, nil
+}
+
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && a == b
+}
+
+func (s *Server) configurationDone(json.RawMessage) (any, func(), error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.vm == nil {
+		return nil, nil, fmt.Errorf("Ծրագիրը բեռնված չէ")
+	}
+	if s.stopOnEntry {
+		return nil, func() { s.stopped("entry", "") }, nil
+	}
+	return nil, func() { s.resume(stepContinue) }, nil
+}
+
+func (s *Server) threads(json.RawMessage) (any, func(), error) {
+	return map[string]any{"threads": []map[string]any{{"id": 1, "name": "main"}}}, nil, nil
+}
+
+// կանչերի ստեկի k-րդ կադրը. ստեկը հասանելի է միայն կանգնած ծրագրի համար
+func (s *Server) frame(k int) (machine.Frame, error) {
+	if s.vm == nil {
+		return machine.Frame{}, fmt.Errorf("Ծրագիրը բեռնված չէ")
+	}
+	if s.running {
+		return machine.Frame{}, fmt.Errorf("Ծրագիրը կատարվում է")
+	}
+	if k < 0 || k >= len(s.frames) {
+		return machine.Frame{}, fmt.Errorf("Անծանոթ կադր %d", k)
+	}
+	return s.frames[k], nil
+}
+
+func (s *Server) stackTrace(json.RawMessage) (any, func(), error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, err := s.frame(0); err != nil {
+		return nil, nil, err
+	}
+	frames := make([]stackFrame, len(s.frames))
+	for k, f := range s.frames {
+		frames[k] = stackFrame{
+			ID:                          k,
+			Name:                        fmt.Sprintf("%s+%d", f.Function, f.Offset),
+			Source:                      source{Name: filepath.Base(s.path), Path: s.path},
+			Line:                        f.Line,
+			Column:                      1,
+			InstructionPointerReference: fmt.Sprintf("0x%04x", f.IP),
+		}
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil, nil
+}
+
+// Փոփոխականների խմբերի համարները կազմվում են կադրի համարից և խմբի
+// տեսակից՝ 4 * կադր + տեսակ
+const (
+	scopeRegisters = iota + 1 // ռեգիստրները
+	scopeArguments            // ենթածրագրի արգումենտները
+	scopeLocals               // կադրի բառերը FP-ից մինչև հաջորդ կադրը
+	scopeStack                // ստեկի գագաթի բառերը
+	scopeKinds     = 4
+)
+
+// ցուցադրվող բառերի առավելագույն քանակը
+const maxWords = 64
+
+func (s *Server) scopes(raw json.RawMessage) (any, func(), error) {
+	var arguments frameArguments
+	if err := json.Unmarshal(raw, &arguments); err != nil {
+		return nil, nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	f, err := s.frame(arguments.FrameID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reference := func(kind int) int { return scopeKinds*arguments.FrameID + kind }
+	result := []scope{{Name: "Ռեգիստրներ", VariablesReference: reference(scopeRegisters)}}
+	if f.Arguments != nil {
+		result = append(result, scope{Name: "Արգումենտներ", VariablesReference: reference(scopeArguments)})
+	}
+	if arguments.FrameID < len(s.frames)-1 {
+		result = append(result, scope{Name: "Կադր", VariablesReference: reference(scopeLocals)})
+	}
+	if arguments.FrameID == 0 {
+		result = append(result, scope{Name: "Ստեկ", VariablesReference: reference(scopeStack)})
+	}
+	return map[string]any{"scopes": result}, nil, nil
+}
+
+func (s *Server) variables(raw json.RawMessage) (any, func(), error) {
+	var arguments variablesArguments
+	if err := json.Unmarshal(raw, &arguments); err != nil {
+		return nil, nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	reference := arguments.VariablesReference - 1
+	k, kind := reference/scopeKinds, reference%scopeKinds+1
+	f, err := s.frame(k)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := []variable{}
+	add := func(name string, value any) {
+		result = append(result, variable{Name: name, Value: fmt.Sprint(value)})
+	}
+	registers := s.vm.Registers()
+	switch kind {
+	case scopeRegisters:
+		add("IP", fmt.Sprintf("0x%04x", f.IP))
+		if k == 0 {
+			add("SP", registers.SP)
+		}
+		add("FP", f.FP)
+		add("Mode", registers.Mode)
+	case scopeArguments:
+		for n, argument := range f.Arguments {
+			add(fmt.Sprintf("[FP - %d]", 8+4*(len(f.Arguments)-n)), argument)
+		}
+	case scopeLocals, scopeStack:
+		// կադրի բառերն ավարտվում են հաջորդ կադրի արգումենտներից առաջ
+		start, end := f.FP, registers.SP
+		if kind == scopeStack {
+			start = max(end-4*maxWords, 0)
+		} else if k > 0 {
+			inner := s.frames[k-1]
+			end = inner.FP - 8 - 4*int16(len(inner.Arguments))
+		}
+		start = max(start, end-4*maxWords)
+		for address := end - 4; address >= start; address -= 4 {
+			word, err := s.word(int32(address))
+			if err != nil {
+				break
+			}
+			if kind == scopeStack {
+				add(fmt.Sprintf("[SP - %d]", registers.SP-address), word)
+			} else {
+				add(fmt.Sprintf("[FP + %d]", address-f.FP), word)
+			}
+		}
+	}
+	return map[string]any{"variables": result}, nil, nil
+}
+
+// կարդալ հիշողության բառը
+func (s *Server) word(address int32) (int32, error) {
+	data, err := s.vm.ReadMemory(address, 4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(data)), nil
+}
+
+// հաշվել արտահայտությունը վերին կադրում
+func (s *Server) evaluate(raw json.RawMessage) (any, func(), error) {
+	var arguments struct {
+		evaluateArguments
+		FrameID int `json:"frameId"`
+	}
+	if err := json.Unmarshal(raw, &arguments); err != nil {
+		return nil, nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	f, err := s.frame(arguments.FrameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	registers := s.vm.Registers()
+	registers.IP, registers.FP = f.IP, f.FP
+	value, err := s.calculate(arguments.Expression, registers)
+	if err != nil {
+		return nil, nil, err
+	}
+	return map[string]any{"result": fmt.Sprint(value), "variablesReference": 0}, nil, nil
+}
+
+func (s *Server) pauseRequest(json.RawMessage) (any, func(), error) {
+	s.pause.Store(true)
+	return nil, nil, nil
+}
+
+// կանգնեցնել ծրագիրը. terminate-ից հետո ծրագիրն այլևս չի շարունակվում
+func (s *Server) terminate(json.RawMessage) (any, func(), error) {
+	s.pause.Store(true)
+	s.done.Wait()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.vm == nil || s.finished {
+		return nil, nil, nil
+	}
+	s.finished = true
+	return nil, func() { s.sendEvent("terminated", nil) }, nil
+}
+
+// կատարման ռեժիմները
+type stepMode int
+
+const (
+	stepContinue stepMode = iota // մինչև կանգառի կետը
+	stepIn                       // մինչև նոր տողը
+	stepOver                     // մինչև նոր տողը նույն կամ կանչող կադրում
+	stepOut                      // մինչև կանչող կադրը
+)
+
+func stepper(mode stepMode) handler {
+	return func(s *Server, raw json.RawMessage) (any, func(), error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if s.vm == nil {
+			return nil, nil, fmt.Errorf("Ծրագիրը բեռնված չէ")
+		}
+		if s.running {
+			return nil, nil, fmt.Errorf("Ծրագիրը կատարվում է")
+		}
+		return map[string]any{"allThreadsContinued": true}, func() { s.resume(mode) }, nil
+	}
+}
+
+// շարունակել ծրագրի կատարումը mode ռեժիմով
+func (s *Server) resume(mode stepMode) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.running {
+		return
+	}
+	if s.finished {
+		s.sendEvent("terminated", nil)
+		return
+	}
+	registers := s.vm.Registers()
+	s.running = true
+	s.pause.Store(false)
+	s.done.Add(1)
+	go s.run(mode, s.program.Lines[int(registers.IP)], registers.FP)
+}
+
+// կատարել ծրագիրը, մինչև mode ռեժիմի պայմանը, կանգառի կետը, pause
+// հարցումը, ծուղակը կամ ծրագրի ավարտը
+func (s *Server) run(mode stepMode, line int, fp int16) {
+	defer s.done.Done()
+	for first := true; ; first = false {
+		if s.pause.Load() {
+			s.stopped("pause", "")
+			return
+		}
+		registers := s.vm.Registers()
+		s.mutex.Lock()
+		_, breakpoint := s.breakpoints[registers.IP]
+		s.mutex.Unlock()
+		if breakpoint && !first {
+			s.stopped("breakpoint", "")
+			return
+		}
+
+		running, err := s.vm.Step()
+		if err != nil {
+			s.mutex.Lock()
+			s.finished = true
+			s.mutex.Unlock()
+			s.stopped("exception", err.Error())
+			return
+		}
+		if !running {
+			s.exited()
+			return
+		}
+
+		registers = s.vm.Registers()
+		current := s.program.Lines[int(registers.IP)]
+		var reached bool
+		switch mode {
+		case stepIn:
+			reached = current != 0 && (current != line || registers.FP != fp)
+		case stepOver:
+			reached = current != 0 && registers.FP <= fp && (current != line || registers.FP < fp)
+		case stepOut:
+			reached = current != 0 && registers.FP < fp
+		}
+		if reached {
+			s.stopped("step", "")
+			return
+		}
+	}
+}
+
+// ծրագիրը կանգնել է. պահպանել կանչերի ստեկը և տեղեկացնել հաճախորդին
+func (s *Server) stopped(reason, text string) {
+	s.mutex.Lock()
+	s.running = false
+	s.frames = s.vm.Backtrace()
+	s.mutex.Unlock()
+	body := map[string]any{"reason": reason, "threadId": 1, "allThreadsStopped": true}
+	if text != "" {
+		body["text"] = text
+	}
+	s.sendEvent("stopped", body)
+}
+
+// ծրագիրն ավարտվել է HALT հրամանով
+func (s *Server) exited() {
+	s.mutex.Lock()
+	s.running, s.finished = false, true
+	s.mutex.Unlock()
+	s.sendEvent("exited", map[string]any{"exitCode": s.vm.ExitCode()})
+	s.sendEvent("terminated", nil)
+}
@@ -0,0 +1,256 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// max(a, b) ենթածրագիրը և դրա կանչը INPUT-ով կարդացված թվերով
+const example = `  CALL main
+  HALT
+max:
+  PUSH [FP - 16]
+  PUSH [FP - 12]
+  GT
+  JZ second
+  PUSH [FP - 16]
+  RET 2
+second:
+  PUSH [FP - 12]
+  RET 2
+main:
+  INPUT
+  INPUT
+  CALL max
+  PRINT
+  PUSH 0
+  RET
+`
+
+// հաճախորդը, որը խոսում է սերվերի հետ խողովակներով
+type client struct {
+	t        *testing.T
+	writer   io.Writer
+	seq      int
+	messages chan map[string]any
+	done     chan error
+}
+
+func newClient(t *testing.T) *client {
+	requests, requestWriter := io.Pipe()
+	responseReader, responses := io.Pipe()
+	c := &client{t: t, writer: requestWriter, messages: make(chan map[string]any, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer(requests, responses).Serve()
+		responses.Close()
+	}()
+	go func() {
+		reader := bufio.NewReader(responseReader)
+		for {
+			body, err := readMessage(reader)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			var message map[string]any
+			if err := json.Unmarshal(body, &message); err != nil {
+				t.Errorf("Սխալ հաղորդագրություն։ (%v)", err)
+			}
+			c.messages <- message
+		}
+	}()
+	t.Cleanup(func() { requestWriter.Close() })
+	return c
+}
+
+// հաջորդ հաղորդագրությունը, որը բավարարում է match պայմանին. մյուսները
+// բաց են թողնվում
+func (c *client) wait(match func(map[string]any) bool) map[string]any {
+	c.t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case message, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("Սերվերը փակեց կապը")
+			}
+			if match(message) {
+				return message
+			}
+		case <-timeout:
+			c.t.Fatalf("Հաղորդագրությունը չստացվեց")
+		}
+	}
+}
+
+// ուղարկել հարցումը և սպասել դրա հաջող պատասխանին
+func (c *client) request(command string, arguments any) map[string]any {
+	c.t.Helper()
+	c.seq++
+	seq := c.seq
+	if err := writeMessage(c.writer, map[string]any{"seq": seq, "type": "request", "command": command, "arguments": arguments}); err != nil {
+		c.t.Fatalf("Չկարողացա ուղարկել հարցումը։ (%v)", err)
+	}
+	reply := c.wait(func(m map[string]any) bool {
+		return m["type"] == "response" && m["request_seq"] == float64(seq)
+	})
+	if reply["success"] != true {
+		c.t.Fatalf("%s հարցումը ձախողվեց. %v", command, reply["message"])
+	}
+	body, _ := reply["body"].(map[string]any)
+	return body
+}
+
+// սպասել name իրադարձությանը
+func (c *client) event(name string) map[string]any {
+	c.t.Helper()
+	message := c.wait(func(m map[string]any) bool { return m["type"] == "event" && m["event"] == name })
+	body, _ := message["body"].(map[string]any)
+	return body
+}
+
+// սպասել stopped իրադարձությանը և ստուգել պատճառը
+func (c *client) stopped(reason string) map[string]any {
+	c.t.Helper()
+	body := c.event("stopped")
+	if body["reason"] != reason {
+		c.t.Fatalf("Սպասվում է %s կանգառ, բայց ստացվել է %v", reason, body)
+	}
+	return body
+}
+
+// վերին կադրի տողը
+func (c *client) line() int {
+	c.t.Helper()
+	frames := c.request("stackTrace", map[string]any{"threadId": 1})["stackFrames"].([]any)
+	return int(frames[0].(map[string]any)["line"].(float64))
+}
+
+func launch(t *testing.T, source string, arguments map[string]any) (*client, string) {
+	file := filepath.Join(t.TempDir(), "example.asm")
+	if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+		t.Fatalf("Չկարողացա ստեղծել ֆայլը։ (%v)", err)
+	}
+	c := newClient(t)
+	capabilities := c.request("initialize", map[string]any{"adapterID": "svm"})
+	if capabilities["supportsConfigurationDoneRequest"] != true {
+		t.Errorf("Սխալ հնարավորություններ. %v", capabilities)
+	}
+	arguments["program"] = file
+	c.request("launch", arguments)
+	c.event("initialized")
+	return c, file
+}
+
+func TestDebugSession(t *testing.T) {
+	c, file := launch(t, example, map[string]any{"input": "3 5"})
+
+	// 3-րդ տողում միայն պիտակն է, իսկ 100-րդ տող չկա
+	body := c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": file},
+		"breakpoints": []any{map[string]any{"line": 3}, map[string]any{"line": 100}},
+	})
+	breakpoints := body["breakpoints"].([]any)
+	if first := breakpoints[0].(map[string]any); first["verified"] != true || first["line"] != float64(4) {
+		t.Errorf("Սպասվում է 4-րդ տողի կանգառի կետ, բայց ստացվել է %v", first)
+	}
+	if second := breakpoints[1].(map[string]any); second["verified"] != false {
+		t.Errorf("Սպասվում է չհաստատված կանգառի կետ, բայց ստացվել է %v", second)
+	}
+
+	c.request("configurationDone", nil)
+	c.stopped("breakpoint")
+
+	frames := c.request("stackTrace", map[string]any{"threadId": 1})["stackFrames"].([]any)
+	if len(frames) != 3 {
+		t.Fatalf("Սպասվում է 3 կադր, բայց ստացվել է %v", frames)
+	}
+	top, caller := frames[0].(map[string]any), frames[1].(map[string]any)
+	if top["name"] != "max+0" || top["line"] != float64(4) {
+		t.Errorf("Սխալ կադր. %v", top)
+	}
+	if caller["line"] != float64(16) {
+		t.Errorf("Սխալ կանչող կադր. %v", caller)
+	}
+
+	scopes := c.request("scopes", map[string]any{"frameId": 0})["scopes"].([]any)
+	var reference float64
+	for _, s := range scopes {
+		if s := s.(map[string]any); s["name"] == "Արգումենտներ" {
+			reference = s["variablesReference"].(float64)
+		}
+	}
+	variables := c.request("variables", map[string]any{"variablesReference": reference})["variables"].([]any)
+	if len(variables) != 2 || variables[0].(map[string]any)["value"] != "3" || variables[1].(map[string]any)["value"] != "5" {
+		t.Errorf("Սպասվում են 3 և 5 արգումենտները, բայց ստացվել է %v", variables)
+	}
+
+	for expression, expected := range map[string]string{
+		"[FP - 16] + [FP - 12]": "8",
+		"max":                   "4",
+		"-(FP - FP) + 0x10":     "16",
+	} {
+		result := c.request("evaluate", map[string]any{"expression": expression, "frameId": 0})
+		if result["result"] != expected {
+			t.Errorf("%s. սպասվում է %s, բայց ստացվել է %v", expression, expected, result["result"])
+		}
+	}
+
+	c.request("next", map[string]any{"threadId": 1})
+	c.stopped("step")
+	if line := c.line(); line != 5 {
+		t.Errorf("Սպասվում է 5-րդ տողը, բայց ստացվել է %d", line)
+	}
+
+	c.request("stepOut", map[string]any{"threadId": 1})
+	c.stopped("step")
+	if line := c.line(); line != 17 {
+		t.Errorf("Սպասվում է 17-րդ տողը, բայց ստացվել է %d", line)
+	}
+
+	c.request("continue", map[string]any{"threadId": 1})
+	if output := c.event("output"); output["output"] != "5\n" {
+		t.Errorf("Սպասվում է 5, բայց ստացվել է %v", output)
+	}
+	if exited := c.event("exited"); exited["exitCode"] != float64(0) {
+		t.Errorf("Սխալ ավարտի կոդ. %v", exited)
+	}
+	c.event("terminated")
+
+	c.request("disconnect", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("Անսպասելի սխալ։ (%v)", err)
+	}
+}
+
+func TestDebugTrapAndPause(t *testing.T) {
+	c, _ := launch(t, "  PUSH 1\n  PUSH 0\n  DIV\n  HALT\n", map[string]any{"stopOnEntry": true})
+	c.request("configurationDone", nil)
+	c.stopped("entry")
+	if line := c.line(); line != 1 {
+		t.Errorf("Սպասվում է 1-ին տողը, բայց ստացվել է %d", line)
+	}
+	c.request("continue", map[string]any{"threadId": 1})
+	if stopped := c.stopped("exception"); stopped["text"] == "" {
+		t.Errorf("Ծուղակի նկարագրությունը բացակայում է")
+	}
+	if line := c.line(); line != 3 {
+		t.Errorf("Սպասվում է 3-րդ տողը, բայց ստացվել է %d", line)
+	}
+	c.request("continue", map[string]any{"threadId": 1})
+	c.event("terminated")
+
+	c, _ = launch(t, "loop:\n  JUMP loop\n", map[string]any{})
+	c.request("configurationDone", nil)
+	c.request("pause", map[string]any{"threadId": 1})
+	c.stopped("pause")
+	if line := c.line(); line != 2 {
+		t.Errorf("Սպասվում է 2-րդ տողը, բայց ստացվել է %d", line)
+	}
+	c.request("disconnect", nil)
+}
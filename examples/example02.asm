@@ -0,0 +1,24 @@
+; երկու թվերից մեծը
+  CALL main
+  HALT
+
+main:
+  INPUT
+  INPUT
+  CALL max
+  PRINT
+  PUSH 0
+  RET
+
+; max(a, b)
+max:
+  PUSH [FP - 16]
+  PUSH [FP - 12]
+  GT
+  JZ second
+  PUSH [FP - 16]
+  JUMP endf
+second:
+  PUSH [FP - 12]
+endf:
+  RET 2
@@ -0,0 +1,16 @@
+; արգումենտների քանակը և ավարտի կոդը
+  CALL main
+  HALT 0
+
+; main(argc, argv)
+main:
+  PUSH [FP - 16]
+  PRINT
+  PUSH [FP - 16]
+  JZ none
+  PUSH [FP - 16]
+  EPRINT
+  HALT 3
+none:
+  PUSH 0
+  RET 2
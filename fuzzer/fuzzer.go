@@ -0,0 +1,263 @@
+package fuzzer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"svm/bytecode"
+	"svm/coverage"
+	"svm/machine"
+)
+
+// Ֆազերը ծրագիրը կատարում է բազմաթիվ անգամ՝ ամեն անգամ INPUT-ի
+// տարբեր արժեքներով։ Ներածումները ստացվում են նախորդ ներածումների
+// փոփոխությամբ (մուտացիայով), իսկ այն ներածումները, որոնք կատարում են
+// ծրագրի նոր տողեր կամ JZ-ի նոր ճյուղեր, ավելացվում են հետագա
+// փոփոխությունների համար։ Մուտացիաներում օգտագործվում են նաև ծրագրի
+// PUSH n հրամանների հաստատունները և դրանց հարևան թվերը։ Ծուղակով,
+// այդ թվում՝ ASSERT-ի ձախողմամբ կամ քայլերի սահմանի սպառմամբ ավարտվող
+// կատարումների ներածումները պարզեցվում են և հաղորդվում։ Պատահական
+// թվերի աղբյուրը որոշվում է Config.Seed-ով, այնպես որ նույն
+// կարգավորումներով ֆազերը միշտ նույն արդյունքն է տալիս։
+
+// ֆազերի կարգավորումները
+type Config struct {
+	Seed   uint64 // պատահական թվերի աղբյուրի սկզբնական արժեքը
+	Runs   int    // կատարումների քանակը
+	Steps  int    // մեկ կատարման քայլերի առավելագույն քանակը
+	Inputs int    // ներածման թվերի առավելագույն քանակը
+}
+
+// լռելյայն կարգավորումները
+var DefaultConfig = Config{Seed: 1, Runs: 10000, Steps: 100000, Inputs: 16}
+
+// ծուղակով ավարտված կատարումը
+type Finding struct {
+	Input     []int32         // պարզեցված ներածումը
+	Trap      *machine.Trap   // ծուղակը
+	Backtrace []machine.Frame // կանչերի ստեկը ծուղակի պահին
+}
+
+// ֆազերի արդյունքը
+type Result struct {
+	Runs     int               // կատարումների քանակը
+	Coverage *coverage.Profile // բոլոր կատարումների ծածկույթը
+	Findings []Finding         // ծուղակներն՝ ամեն տեսակի և հասցեի համար մեկը
+}
+
+// ֆազել program ծրագիրը, file-ը ծրագրի տեքստի ֆայլն է։ Config-ի
+// զրոյական Steps-ի և Inputs-ի փոխարեն օգտագործվում են լռելյայն արժեքները
+func Fuzz(file string, program *bytecode.Program, config Config) Result {
+	if config.Steps <= 0 {
+		config.Steps = DefaultConfig.Steps
+	}
+	if config.Inputs <= 0 {
+		config.Inputs = DefaultConfig.Inputs
+	}
+	seeds := [][]int32{{}, {0}, {1}, {-1}}
+	f := &fuzzer{
+		file:    file,
+		program: program,
+		config:  config,
+		random:  rand.New(rand.NewPCG(config.Seed, config.Seed)),
+		corpus:  slices.Clone(seeds),
+		found:   map[[2]int32]bool{},
+		values:  slices.Clone(interesting),
+	}
+	for address := range program.Lines {
+		if address+5 <= len(program.Code) && program.Code[address] == bytecode.Push|bytecode.Immediate {
+			n := int32(binary.LittleEndian.Uint32(program.Code[address+1:]))
+			f.values = append(f.values, n-1, n, n+1)
+		}
+	}
+	slices.Sort(f.values)
+	f.values = slices.Compact(f.values)
+	result := Result{Coverage: coverage.NewProfile(file, program)}
+
+	for ; result.Runs < config.Runs; result.Runs++ {
+		var input []int32
+		if result.Runs < len(seeds) {
+			input = seeds[result.Runs]
+		} else {
+			input = f.mutate(f.corpus[f.random.IntN(len(f.corpus))])
+		}
+
+		profile, trap := f.run(input)
+		if result.Runs >= len(seeds) && covers(result.Coverage, profile) {
+			f.corpus = append(f.corpus, input)
+		}
+		result.Coverage.Merge(profile)
+
+		if trap != nil && !f.found[key(trap)] {
+			f.found[key(trap)] = true
+			finding := f.minimize(input, trap)
+			result.Findings = append(result.Findings, finding)
+		}
+	}
+	return result
+}
+
+type fuzzer struct {
+	file    string
+	program *bytecode.Program
+	config  Config
+	random  *rand.Rand
+	corpus  [][]int32         // նոր ծածկույթ տված ներածումները
+	found   map[[2]int32]bool // հայտնաբերված ծուղակները՝ ըստ key-ի
+	values  []int32           // հետաքրքիր արժեքները և ծրագրի հաստատունները
+}
+
+// ծուղակի տեսակը և հասցեն. քայլերի սահմանի սպառման հասցեն պատահական է
+func key(trap *machine.Trap) [2]int32 {
+	if trap.Code == machine.TrapStepLimit {
+		return [2]int32{int32(trap.Code), 0}
+	}
+	return [2]int32{int32(trap.Code), int32(trap.IP)}
+}
+
+// կատարել ծրագիրը input ներածմամբ
+func (f *fuzzer) run(input []int32) (*coverage.Profile, *machine.Trap) {
+	m, profile := f.machine(input)
+	defer m.Close()
+	var trap *machine.Trap
+	if err := m.Run(); !errors.As(err, &trap) {
+		trap = nil
+	}
+	return profile, trap
+}
+
+// input ներածմամբ մեքենա, որի կատարումը գրանցվում է profile-ում
+func (f *fuzzer) machine(input []int32) (*machine.Machine, *coverage.Profile) {
+	text := make([]string, len(input))
+	for k, value := range input {
+		text[k] = fmt.Sprint(value)
+	}
+	profile := coverage.NewProfile(f.file, f.program)
+	m := machine.NewMachine(
+		machine.WithStdin(strings.NewReader(strings.Join(text, " "))),
+		machine.WithStdout(io.Discard),
+		machine.WithStderr(io.Discard),
+		machine.WithStepLimit(f.config.Steps),
+		machine.WithObserver(profile.Recorder(f.program)))
+	m.LoadProgram(f.program)
+	return m, profile
+}
+
+// արդյոք profile-ը կատարել է total-ում չկատարված տող կամ ճյուղ
+func covers(total, profile *coverage.Profile) bool {
+	for line, count := range profile.Lines {
+		if count > 0 && total.Lines[line] == 0 {
+			return true
+		}
+	}
+	for line, branch := range profile.Branches {
+		old := total.Branches[line]
+		if branch.Taken > 0 && old.Taken == 0 || branch.NotTaken > 0 && old.NotTaken == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// հետաքրքիր արժեքներ, որոնցով ծրագրերը հաճախ սխալվում են
+var interesting = []int32{0, 1, -1, 2, 10, 100, math.MaxInt32, math.MinInt32}
+
+// input-ի պատահական փոփոխությունը
+func (f *fuzzer) mutate(input []int32) []int32 {
+	input = slices.Clone(input)
+	for range 1 + f.random.IntN(3) {
+		k := 0
+		if len(input) > 0 {
+			k = f.random.IntN(len(input))
+		}
+		switch operation := f.random.IntN(6); {
+		case len(input) == 0 || operation == 0:
+			if len(input) < f.config.Inputs {
+				input = slices.Insert(input, f.random.IntN(len(input)+1), f.value())
+			}
+		case operation == 1:
+			input = slices.Delete(input, k, k+1)
+		case operation == 2:
+			input[k] = f.values[f.random.IntN(len(f.values))]
+		case operation == 3:
+			input[k] += int32(f.random.IntN(33) - 16)
+		case operation == 4:
+			input[k] = -input[k]
+		default:
+			input[k] = f.value()
+		}
+	}
+	return input
+}
+
+// պատահական արժեք՝ հիմնականում փոքր թվերից
+func (f *fuzzer) value() int32 {
+	if f.random.IntN(2) == 0 {
+		return f.values[f.random.IntN(len(f.values))]
+	}
+	return int32(f.random.IntN(201) - 100)
+}
+
+// պարզեցնել input-ը՝ պահպանելով trap ծուղակը. հեռացնել ավելորդ թվերը
+// և մնացածները կիսման եղանակով մոտեցնել 0-ին։ Պարզեցման կատարումների
+// քանակը չի գերազանցում Config.Runs-ը
+func (f *fuzzer) minimize(input []int32, trap *machine.Trap) Finding {
+	budget := f.config.Runs
+	same := func(candidate []int32) bool {
+		if budget <= 0 {
+			return false
+		}
+		budget--
+		_, other := f.run(candidate)
+		return other != nil && key(other) == key(trap)
+	}
+
+	input = slices.Clone(input)
+	for changed := true; changed && budget > 0; {
+		changed = false
+		for k := len(input) - 1; k >= 0; k-- {
+			if candidate := slices.Delete(slices.Clone(input), k, k+1); same(candidate) {
+				input, changed = candidate, true
+			}
+		}
+		for k := range input {
+			if input[k] == 0 {
+				continue
+			}
+			candidate := slices.Clone(input)
+			candidate[k] = 0
+			if same(candidate) {
+				input, changed = candidate, true
+				continue
+			}
+			// far-ը պահպանում է ծուղակը, near-ը՝ ոչ. int64-ով տարբերությունը
+			// չի գերլցվում
+			near, far := int64(0), int64(input[k])
+			for far-near > 1 || near-far > 1 {
+				middle := near + (far-near)/2
+				candidate[k] = int32(middle)
+				if same(candidate) {
+					far = middle
+				} else {
+					near = middle
+				}
+			}
+			if int32(far) != input[k] {
+				input[k], changed = int32(far), true
+			}
+		}
+	}
+
+	// կրկնել պարզեցված կատարումը՝ ծուղակն ու կանչերի ստեկը ստանալու համար
+	m, _ := f.machine(input)
+	defer m.Close()
+	if err := m.Run(); err != nil {
+		errors.As(err, &trap)
+	}
+	return Finding{Input: input, Trap: trap, Backtrace: m.Backtrace()}
+}
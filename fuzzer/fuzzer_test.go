@@ -0,0 +1,114 @@
+package fuzzer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"svm/assembler"
+	"svm/machine"
+	"testing"
+)
+
+// ծրագիրը 3-ի դեպքում բաժանում է 0-ի, 7-ի և 1000-ից մեծ թվի դեպքում
+// ձախողում է պնդումը, իսկ -5-ի դեպքում երբեք չի ավարտվում
+const example = `  CALL main
+  HALT
+main:
+  ENTER 2
+  INPUT
+  POP [FP + 0]
+  INPUT
+  POP [FP + 4]
+  PUSH 100
+  PUSH [FP + 0]
+  PUSH 3
+  SUB
+  DIV
+  PRINT
+  PUSH [FP + 0]
+  PUSH 7
+  EQ
+  JZ other
+  PUSH [FP + 4]
+  PUSH 1000
+  LE
+  ASSERT
+other:
+  PUSH [FP + 0]
+  PUSH -5
+  NE
+  JZ other
+  PUSH 0
+  RET
+`
+
+func TestFuzz(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "example.asm")
+	if err := os.WriteFile(file, []byte(example), 0o644); err != nil {
+		t.Fatalf("Չկարողացա ստեղծել ֆայլը։ (%v)", err)
+	}
+	program, err := assembler.AssembleProgram(file)
+	if err != nil {
+		t.Fatalf("Ասեմբլերի սխալ։ (%v)", err)
+	}
+
+	config := DefaultConfig
+	config.Runs = 5000
+	config.Steps = 1000
+	result := Fuzz(file, program, config)
+	if result.Runs != config.Runs {
+		t.Errorf("Սպասվում է %d կատարում, բայց ստացվել է %d", config.Runs, result.Runs)
+	}
+
+	expected := map[machine.TrapCode][]int32{
+		machine.TrapDivisionByZero: {3},
+		machine.TrapAssertion:      {7, 1001},
+		machine.TrapStepLimit:      {-5},
+	}
+	for _, finding := range result.Findings {
+		input, ok := expected[finding.Trap.Code]
+		if !ok {
+			t.Errorf("Անսպասելի ծուղակ. %v", finding.Trap)
+			continue
+		}
+		delete(expected, finding.Trap.Code)
+		if !slices.Equal(finding.Input, input) {
+			t.Errorf("%v ծուղակի համար սպասվում է %v ներածումը, բայց ստացվել է %v", finding.Trap, input, finding.Input)
+		}
+		if len(finding.Backtrace) == 0 {
+			t.Errorf("Սպասվում է կանչերի ստեկ")
+		}
+	}
+	for code := range expected {
+		t.Errorf("Չի հայտնաբերվել %v ծուղակը", code)
+	}
+	if s := result.Coverage.Summary(); s.CoveredBranches != s.Branches {
+		t.Errorf("Սպասվում է բոլոր ճյուղերի ծածկույթ, բայց ստացվել է %v", s)
+	}
+
+	// նույն կարգավորումներով արդյունքը նույնն է
+	again := Fuzz(file, program, config)
+	if len(again.Findings) != len(result.Findings) || !slices.Equal(again.Findings[0].Input, result.Findings[0].Input) {
+		t.Errorf("Ֆազերի արդյունքը կախված չէ միայն Seed-ից")
+	}
+}
+
+func TestMinimizeLargeValue(t *testing.T) {
+	// 1500000000-ից մեծ կամ հավասար թվերի դեպքում պնդումը ձախողվում է
+	file := filepath.Join(t.TempDir(), "large.asm")
+	text := "  INPUT\n  PUSH 1500000000\n  LT\n  ASSERT\n  HALT\n"
+	if err := os.WriteFile(file, []byte(text), 0o644); err != nil {
+		t.Fatalf("Չկարողացա ստեղծել ֆայլը։ (%v)", err)
+	}
+	program, err := assembler.AssembleProgram(file)
+	if err != nil {
+		t.Fatalf("Ասեմբլերի սխալ։ (%v)", err)
+	}
+
+	config := DefaultConfig
+	config.Runs = 200
+	result := Fuzz(file, program, config)
+	if len(result.Findings) != 1 || !slices.Equal(result.Findings[0].Input, []int32{1500000000}) {
+		t.Errorf("Սպասվում է [1500000000] ներածումը, բայց ստացվել է %v", result.Findings)
+	}
+}
@@ -0,0 +1,119 @@
+package machine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"svm/bytecode"
+)
+
+// Backtrace-ը հետևում է կադրերի շղթային. ամեն կադրի [FP - 8] հասցեում
+// CALL-ի պահած վերադարձի հասցեն է, իսկ [FP - 4]-ում՝ կանչողի FP-ն։
+// Շղթան ավարտվում է, երբ FP-ն ցույց է տալիս ստեկի սկզբից առաջ, այսինքն՝
+// ծրագրի սկզբի կամ հոսքի ենթածրագրի կադրին։
+
+// կանչերի ստեկի կադրը
+type Frame struct {
+	IP        int16   // կատարվող հրամանի կամ կանչի հասցեն
+	FP        int16   // կադրի ցուցիչը
+	Function  string  // ենթածրագրի պիտակը կամ սկզբի հասցեն
+	Offset    int16   // IP-ի շեղումը ենթածրագրի սկզբից
+	Line      int     // IP-ին համապատասխանող տողը ծրագրի տեքստում, 0՝ եթե հայտնի չէ
+	Arguments []int32 // ենթածրագրի արգումենտները՝ առաջինից վերջին
+}
+
+func (f Frame) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s+%d [%04x]", f.Function, f.Offset, f.IP)
+	if f.Line != 0 {
+		fmt.Fprintf(&b, " տող %d", f.Line)
+	}
+	if f.Arguments != nil {
+		arguments := make([]string, len(f.Arguments))
+		for k, argument := range f.Arguments {
+			arguments[k] = fmt.Sprint(argument)
+		}
+		fmt.Fprintf(&b, " (%s)", strings.Join(arguments, ", "))
+	}
+	return b.String()
+}
+
+// ծրագիրը բեռնել հիշողության մեջ՝ պահպանելով դրա պիտակներն ու
+// տողերը Backtrace-ի համար
+func (m *Machine) LoadProgram(program *bytecode.Program) {
+	m.Load(program.Code)
+	m.program = program
+}
+
+// ընթացիկ հոսքի կանչերի ստեկը՝ սկսած կատարվող ենթածրագրից
+func (m *Machine) Backtrace() []Frame {
+	defer m.untracked()()
+	defer m.inspect()()
+
+	program := m.program
+	if program == nil {
+		program = &bytecode.Program{}
+	}
+	functions, err := bytecode.Functions(program.Code)
+	if err != nil {
+		functions = nil
+	}
+	names := map[int]string{}
+	for name, address := range program.Symbols {
+		if current, exists := names[address]; !exists || name < current {
+			names[address] = name
+		}
+	}
+
+	frames := []Frame{}
+	ip, fp := m.current, m.fp
+	for len(frames) < maxBacktrace {
+		frame := Frame{IP: ip, FP: fp, Function: "?", Offset: ip, Line: program.Lines[int(ip)]}
+		outermost := fp < m.base+8
+		// ենթածրագիրը, որի սկիզբը IP-ին ամենամոտն է
+		k := slices.IndexFunc(functions, func(f bytecode.Function) bool { return f.Address > int(ip) })
+		if k == -1 {
+			k = len(functions)
+		}
+		if k > 0 {
+			function := functions[k-1]
+			frame.Function = names[function.Address]
+			if frame.Function == "" {
+				frame.Function = fmt.Sprintf("%04x", function.Address)
+			}
+			frame.Offset = ip - int16(function.Address)
+			if !outermost && function.Arguments >= 0 && fp-8-4*int16(function.Arguments) >= m.base {
+				frame.Arguments = make([]int32, function.Arguments)
+			}
+		}
+
+		var caller, next int16
+		err := m.protect(func() {
+			for k := range frame.Arguments {
+				frame.Arguments[k] = m.read(fp - 8 - 4*int16(len(frame.Arguments)-k))
+			}
+			if !outermost {
+				next = int16(m.read(fp - 8))
+				caller = int16(m.read(fp - 4))
+			}
+		})
+		if err != nil {
+			frame.Arguments = nil
+			outermost = true
+		}
+		frames = append(frames, frame)
+		if outermost || caller >= fp {
+			break
+		}
+
+		// կանչողի կադրում IP-ն CALL հրամանի հասցեն է
+		ip, fp = next, caller
+		if ip >= 3 && int(ip) <= len(program.Code) && program.Code[ip-3] == bytecode.Call|bytecode.Indirect {
+			ip -= 3
+		}
+	}
+	return frames
+}
+
+// Backtrace-ի կադրերի առավելագույն քանակը
+const maxBacktrace = 64
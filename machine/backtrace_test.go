@@ -0,0 +1,80 @@
+package machine
+
+import (
+	"errors"
+	"slices"
+	"svm/bytecode"
+	"testing"
+)
+
+func TestBacktrace(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	line := 0
+	add := func(add func()) {
+		line++
+		builder.SetLine(line)
+		add()
+	}
+	add(func() { builder.AddWithNumeric(bytecode.Push, 3) })
+	add(func() { builder.AddWithLabel(bytecode.Call, "countdown") })
+	add(func() { builder.AddBasic(bytecode.Halt) })
+	// countdown(n)-ը ռեկուրսիվ կանչվում է n-1-ով, իսկ n = 0-ի դեպքում
+	// բաժանում է 0-ի
+	builder.SetLabel("countdown")
+	add(func() { builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12) })
+	add(func() { builder.AddWithLabel(bytecode.Jz, "zero") })
+	add(func() { builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12) })
+	add(func() { builder.AddWithNumeric(bytecode.Push, 1) })
+	add(func() { builder.AddBasic(bytecode.Sub) })
+	add(func() { builder.AddWithLabel(bytecode.Call, "countdown") })
+	add(func() { builder.AddWithNumeric(bytecode.Ret, 1) })
+	builder.SetLabel("zero")
+	add(func() { builder.AddWithNumeric(bytecode.Push, 1) })
+	add(func() { builder.AddWithNumeric(bytecode.Push, 0) })
+	add(func() { builder.AddBasic(bytecode.Div) })
+	add(func() { builder.AddWithNumeric(bytecode.Ret, 1) })
+	builder.Validate()
+
+	m := NewMachine()
+	m.LoadProgram(builder.Program())
+	var trap *Trap
+	if err := m.Run(); !errors.As(err, &trap) || trap.Code != TrapDivisionByZero {
+		t.Fatalf("Սպասվում է 0-ի բաժանում, բայց ստացվել է %v", err)
+	}
+
+	frames := m.Backtrace()
+	if len(frames) != 5 {
+		t.Fatalf("Սպասվում է 5 կադր, բայց ստացվել է %v", frames)
+	}
+	if frames[0].String() != "countdown+33 [002a] տող 13 (0)" {
+		t.Errorf("Սխալ կադր. %s", frames[0])
+	}
+	for k, frame := range frames[1:4] {
+		if frame.Function != "countdown" || frame.Line != 9 || !slices.Equal(frame.Arguments, []int32{int32(k + 1)}) {
+			t.Errorf("Սխալ կադր. %s", frame)
+		}
+	}
+	if outermost := frames[4]; outermost.String() != "0000+5 [0005] տող 2" {
+		t.Errorf("Սխալ կադր. %s", outermost)
+	}
+}
+
+func TestBacktraceWithoutSymbols(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithLabel(bytecode.Call, "f")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("f")
+	builder.AddWithNumeric(bytecode.Push, 5)
+	builder.AddBasic(bytecode.Throw)
+	builder.Validate()
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	if err := m.Run(); err == nil {
+		t.Fatalf("Սպասվում է սխալ")
+	}
+	frames := m.Backtrace()
+	if len(frames) != 2 || frames[0].String() != "0004+5 [0009]" || frames[1].Function != "0000" {
+		t.Errorf("Սխալ կադրեր. %v", frames)
+	}
+}
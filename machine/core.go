@@ -0,0 +1,118 @@
+package machine
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"slices"
+	"svm/bytecode"
+)
+
+// Core-ը մեքենայի վիճակն է ծուղակի պահին, որը կարելի է պահել ֆայլում
+// և հետագայում ուսումնասիրել առանց ծրագիրը նորից կատարելու
+type Core struct {
+	Memory    []byte            // հիշողությունը
+	Code      []byte            // հրամանների հիշողությունը հարվարդյան ռեժիմում
+	Registers Registers         // ռեգիստրները. IP-ն ծուղակն առաջացրած հրամանի հասցեն է
+	HP        int16             // բացառությունների վերջին մշակիչի ցուցիչը
+	Base      int16             // ընթացիկ հոսքի ստեկի սկիզբը
+	Control   []int32           // կառավարման ռեգիստրները
+	Trap      *Trap             // ծուղակը
+	Program   *bytecode.Program // ծրագիրը՝ պիտակներով և տողերով
+}
+
+// core ֆայլի սկիզբը
+const coreMagic = "svm core 1\n"
+
+// մեքենայի ընթացիկ վիճակը trap ծուղակից հետո
+func (m *Machine) Core(trap *Trap) *Core {
+	registers := m.Registers()
+	registers.IP = m.current
+	return &Core{
+		Memory:    slices.Clone(m.memory),
+		Code:      slices.Clone(m.code),
+		Registers: registers,
+		HP:        m.hp,
+		Base:      m.base,
+		Control:   slices.Clone(m.control[:]),
+		Trap:      trap,
+		Program:   m.program,
+	}
+}
+
+// գրել core-ը writer-ում
+func (c *Core) Write(writer io.Writer) error {
+	w := bufio.NewWriter(writer)
+	w.WriteString(coreMagic)
+	if err := gob.NewEncoder(w).Encode(c); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// կարդալ Write-ով գրված core-ը
+func ReadCore(reader io.Reader) (*Core, error) {
+	r := bufio.NewReader(reader)
+	magic := make([]byte, len(coreMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != coreMagic {
+		return nil, fmt.Errorf("Ֆայլը svm-ի core ֆայլ չէ")
+	}
+	c := &Core{}
+	if err := gob.NewDecoder(r).Decode(c); err != nil {
+		return nil, fmt.Errorf("Core ֆայլը վնասված է։ (%v)", err)
+	}
+	if len(c.Memory) != MemorySize || len(c.Control) != controlRegisters {
+		return nil, fmt.Errorf("Core ֆայլը վնասված է")
+	}
+	if c.Program == nil {
+		c.Program = &bytecode.Program{}
+	}
+	return c, nil
+}
+
+// մեքենա՝ core-ի վիճակով, որը կարելի է ուսումնասիրել Backtrace-ով
+// և ReadMemory-ով
+func (c *Core) Machine() *Machine {
+	m := NewMachine()
+	copy(m.memory, c.Memory)
+	if len(c.Code) > 0 {
+		m.harvard = true
+		m.code = slices.Clone(c.Code)
+	}
+	m.ip, m.sp, m.fp, m.hp = c.Registers.IP, c.Registers.SP, c.Registers.FP, c.HP
+	m.current = c.Registers.IP
+	m.thread = c.Registers.Thread
+	m.base = c.Base
+	copy(m.control[:], c.Control)
+	m.program = c.Program
+	return m
+}
+
+// կարդալ address հասցեից size բայթ՝ ընթացիկ հասցեային տարածությունում.
+// տիրույթը ստուգվում է նախքան բուֆերի ստեղծումը
+func (m *Machine) ReadMemory(address int32, size int) ([]byte, error) {
+	if address < 0 || size < 0 || int64(address)+int64(size) > int64(m.addressSpace()) {
+		return nil, fmt.Errorf("Տիրույթը (%d, %d) դուրս է հասցեային տարածությունից", address, size)
+	}
+	defer m.untracked()()
+	defer m.inspect()()
+	buffer := make([]byte, size)
+	err := m.protect(func() { m.readBytes(address, buffer, accessRead) })
+	if err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+// address հասցեում գրել data-ի բայթերը ընթացիկ հասցեային տարածությունում՝
+// անտեսելով հիշողության պաշտպանությունը, որպեսզի կարգաբերիչը կարողանա
+// փոխել նաև ծրագրի հրամանները
+func (m *Machine) WriteMemory(address int32, data []byte) error {
+	defer m.untracked()()
+	defer m.inspect()()
+	protected := m.protected
+	m.protected = nil
+	defer func() { m.protected = protected }()
+	return m.protect(func() { m.writeBytes(address, data) })
+}
@@ -0,0 +1,96 @@
+package machine
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"svm/bytecode"
+	"testing"
+)
+
+func TestCore(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 7)
+	builder.AddWithLabel(bytecode.Call, "fail")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("fail")
+	builder.AddWithNumeric(bytecode.Push, 1234)
+	builder.AddWithNumeric(bytecode.Push, 0x2000)
+	builder.AddBasic(bytecode.Store)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12)
+	builder.AddBasic(bytecode.Throw)
+	builder.AddWithNumeric(bytecode.Ret, 1)
+	builder.Validate()
+
+	m := NewMachine()
+	m.LoadProgram(builder.Program())
+	var trap *Trap
+	if err := m.Run(); !errors.As(err, &trap) {
+		t.Fatalf("Սպասվում է ծուղակ, բայց ստացվել է %v", err)
+	}
+
+	var buffer bytes.Buffer
+	if err := m.Core(trap).Write(&buffer); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	core, err := ReadCore(&buffer)
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	if *core.Trap != *trap || core.Registers.IP != trap.IP {
+		t.Errorf("Սպասվում է %v, բայց ստացվել է %v", trap, core.Trap)
+	}
+	restored := core.Machine()
+	if expected, frames := m.Backtrace(), restored.Backtrace(); len(frames) != 2 || frames[0].String() != expected[0].String() {
+		t.Errorf("Սպասվում է %v, բայց ստացվել է %v", expected, frames)
+	}
+	if word, err := restored.ReadMemory(0x2000, 4); err != nil || !slices.Equal(word, []byte{0xd2, 0x04, 0, 0}) {
+		t.Errorf("Սխալ հիշողություն. %v (%v)", word, err)
+	}
+	for _, size := range []int{-1, math.MaxInt32} {
+		if _, err := restored.ReadMemory(0, size); err == nil {
+			t.Errorf("%d չափի համար սպասվում է սխալ", size)
+		}
+	}
+	if _, err := restored.ReadMemory(MemorySize-2, 4); err == nil {
+		t.Errorf("Սպասվում է սխալ")
+	}
+
+	if _, err := ReadCore(strings.NewReader("not a core")); err == nil {
+		t.Errorf("Սպասվում է սխալ")
+	}
+}
+
+func TestWriteMemory(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddWithNumeric(bytecode.Push, 2)
+	builder.AddBasic(bytecode.Add)
+	builder.AddBasic(bytecode.Print)
+	// ծրագիրն ինքը չի կարող փոխել իր հրամանները
+	builder.AddWithNumeric(bytecode.Push, 7)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.Store)
+	builder.AddBasic(bytecode.Halt)
+
+	var output strings.Builder
+	m := NewMachine(WithStdout(&output))
+	m.Load(builder.Bytes())
+	if err := m.WriteMemory(1, []byte{40, 0, 0, 0}); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if err := m.WriteMemory(MemorySize-2, []byte{0, 0, 0, 0}); err == nil {
+		t.Errorf("Սպասվում է սխալ")
+	}
+
+	var trap *Trap
+	if err := m.Run(); !errors.As(err, &trap) || trap.Code != TrapWriteProtected {
+		t.Errorf("Սպասվում է գրառում պաշտպանված հիշողությունում, բայց ստացվել է %v", err)
+	}
+	if output.String() != "42\n" {
+		t.Errorf("Սպասվում է 42, բայց ստացվել է %q", output.String())
+	}
+}
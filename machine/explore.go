@@ -0,0 +1,179 @@
+package machine
+
+import "slices"
+
+// Ուսումնասիրման ռեժիմում ծրագիրը կատարվում է բազմաթիվ անգամ՝ ամեն
+// անգամ հոսքերի տարբեր հերթագայությամբ։ Որոշման կետ է համարվում այն
+// քայլը, որից հետո կարող է կատարվել մեկից ավելի հոսք։ Լռելյայն շարունակվում
+// է ընթացիկ հոսքը, իսկ չավարտված հոսքից մեկ այլ հոսքի անցնելը համարվում
+// է ընդհատում (preemption)։ Հերթագայությունները թվարկվում են խորությամբ՝
+// ընդհատումների քանակը սահմանափակելով Exploration.Preemptions-ով։
+
+// ուսումնասիրման կարգավորումները
+type Exploration struct {
+	Preemptions int  // ընդհատումների առավելագույն քանակը մեկ կատարման մեջ
+	Runs        int  // կատարումների առավելագույն քանակը, 0՝ առանց սահմանափակման
+	Steps       int  // մեկ կատարման քայլերի առավելագույն քանակը
+	Races       bool // մրցավազքը համարել ձախողում
+}
+
+// լռելյայն քայլերի քանակը մեկ կատարման համար
+const defaultExplorationSteps = 10000
+
+// ձախողված կատարումը
+type Failure struct {
+	Schedule []int  // որոշման կետերում ընտրված հոսքերը, տես WithSchedule
+	Err      error  // ծուղակը, եթե կատարումը կանգնել է սխալով
+	Races    []Race // հայտնաբերված մրցավազքերը
+}
+
+// ուսումնասիրման արդյունքը
+type ExplorationResult struct {
+	Runs       int      // կատարումների քանակը
+	Incomplete int      // կատարումներ, որոնք չեն ավարտվել Steps քայլում
+	Failure    *Failure // առաջին ձախողված կատարումը, nil՝ եթե այդպիսին չկա
+}
+
+// հոսքերի հերթագայությունը որոշել schedule-ով. դրա i-րդ տարրը i-րդ
+// որոշման կետում կատարվող հոսքի համարն է։ Վերջին որոշման կետից հետո
+// շարունակվում է ընթացիկ հոսքը
+func WithSchedule(schedule []int) Option {
+	return func(m *Machine) {
+		m.choose = (&player{prefix: schedule}).choose
+	}
+}
+
+// որոշման կետը
+type decision struct {
+	runnable    []int // կատարման պատրաստ հոսքերը
+	current     int   // մինչ որոշումը կատարվող հոսքը
+	chosen      int   // ընտրված հոսքը
+	preemptions int   // մինչ այս կետն արված ընդհատումների քանակը
+}
+
+// հոսքը, որն ընտրվում է առանց ընդհատման
+func (d decision) fallback() int {
+	if slices.Contains(d.runnable, d.current) {
+		return d.current
+	}
+	return d.runnable[0]
+}
+
+// արդյոք thread հոսքն ընտրելը ընդհատում է
+func (d decision) preempts(thread int) bool {
+	return thread != d.current && slices.Contains(d.runnable, d.current)
+}
+
+// հոսքերի ընտրության հերթականությունը. նախ՝ առանց ընդհատման ընտրվողը
+func (d decision) order() []int {
+	order := []int{d.fallback()}
+	for _, id := range d.runnable {
+		if id != order[0] {
+			order = append(order, id)
+		}
+	}
+	return order
+}
+
+// հոսքերն ընտրում է prefix-ով, իսկ դրանից հետո՝ առանց ընդհատումների
+type player struct {
+	prefix    []int
+	decisions []decision
+}
+
+func (p *player) choose(runnable []int, current int) int {
+	if len(runnable) == 1 {
+		return runnable[0]
+	}
+
+	d := decision{runnable: runnable, current: current}
+	if previous := len(p.decisions); previous > 0 {
+		last := p.decisions[previous-1]
+		d.preemptions = last.preemptions
+		if last.preempts(last.chosen) {
+			d.preemptions++
+		}
+	}
+
+	d.chosen = d.fallback()
+	if k := len(p.decisions); k < len(p.prefix) && slices.Contains(runnable, p.prefix[k]) {
+		d.chosen = p.prefix[k]
+	}
+	p.decisions = append(p.decisions, d)
+	return d.chosen
+}
+
+// ընտրված հոսքերը
+func (p *player) schedule() []int {
+	schedule := make([]int, len(p.decisions))
+	for i, d := range p.decisions {
+		schedule[i] = d.chosen
+	}
+	return schedule
+}
+
+// հաջորդ չուսումնասիրված հերթագայության սկիզբը, false՝ եթե բոլորն
+// ուսումնասիրված են
+func (p *player) next(bound int) ([]int, bool) {
+	for j := len(p.decisions) - 1; j >= 0; j-- {
+		d := p.decisions[j]
+		order := d.order()
+		for _, thread := range order[slices.Index(order, d.chosen)+1:] {
+			cost := d.preemptions
+			if d.preempts(thread) {
+				cost++
+			}
+			if cost <= bound {
+				return append(p.schedule()[:j], thread), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ուսումնասիրել code ծրագրի հոսքերի հերթագայությունները՝ փնտրելով
+// ծուղակով ավարտվող կամ, եթե Races-ը տրված է, մրցավազք պարունակող
+// կատարում։ Ամեն կատարման համար մեքենան ստեղծվում է options
+// կարգավորումներով
+func Explore(code []byte, exploration Exploration, options ...Option) ExplorationResult {
+	steps := exploration.Steps
+	if steps <= 0 {
+		steps = defaultExplorationSteps
+	}
+
+	result := ExplorationResult{}
+	prefix := []int{}
+	for exploration.Runs == 0 || result.Runs < exploration.Runs {
+		p := &player{prefix: prefix}
+		options := slices.Clone(options)
+		if exploration.Races {
+			options = append(options, WithRaceDetector())
+		}
+		m := NewMachine(options...)
+		m.choose = p.choose
+		m.Load(code)
+
+		result.Runs++
+		var err error
+		running := true
+		for step := 0; running && err == nil && step < steps; step++ {
+			running, err = m.execute()
+		}
+		m.Close()
+
+		if err != nil || len(m.Races()) > 0 {
+			result.Failure = &Failure{Schedule: p.schedule(), Err: err, Races: m.Races()}
+			break
+		}
+		if running {
+			result.Incomplete++
+		}
+
+		var found bool
+		prefix, found = p.next(exploration.Preemptions)
+		if !found {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,297 @@
+package machine
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// SYS n հրամանի համակարգային կանչերը։ Արգումենտները ստեկում գրվում
+// են նշված հաջորդականությամբ, իսկ կանչի արդյունքը գրվում է ստեկում
+const (
+	SysOpen  int32 = 1 // path, mode -> ֆայլի համար
+	SysRead  int32 = 2 // file, buffer, length -> կարդացված բայթերի քանակ
+	SysWrite int32 = 3 // file, buffer, length -> գրված բայթերի քանակ
+	SysClose int32 = 4 // file -> 0
+)
+
+// SysOpen կանչի բացման ռեժիմները
+const (
+	OpenRead   int32 = 0 // կարդալու համար
+	OpenWrite  int32 = 1 // գրելու համար, ֆայլը ստեղծվում է կամ դատարկվում
+	OpenAppend int32 = 2 // ֆայլի վերջում գրելու համար
+)
+
+// համակարգային կանչերի սխալների կոդերը
+const (
+	SysErrNotFound     int32 = -1 // ֆայլը գոյություն չունի
+	SysErrPermission   int32 = -2 // գործողությունը թույլատրված չէ
+	SysErrBadFile      int32 = -3 // ֆայլի սխալ համար
+	SysErrInvalid      int32 = -4 // սխալ արգումենտ
+	SysErrIO           int32 = -5 // ներածման-արտածման սխալ
+	SysErrTooManyFiles int32 = -6 // չափազանց շատ բաց ֆայլեր
+)
+
+const (
+	maxOpenFiles  = 16  // բաց ֆայլերի առավելագույն քանակը
+	maxPathLength = 255 // ֆայլի անվան առավելագույն երկարությունը
+)
+
+// ծրագրին հասանելի ֆայլը
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// ծրագրին հասանելի ֆայլային համակարգը. flag-ը os.OpenFile-ի դրոշակներն են
+type FileSystem interface {
+	OpenFile(name string, flag int) (File, error)
+}
+
+// ծրագրին հասանելի դարձնել fsys ֆայլային համակարգը
+func WithFileSystem(fsys FileSystem) Option {
+	return func(m *Machine) {
+		m.files = fsys
+	}
+}
+
+// dir պանակով սահմանափակված ֆայլային համակարգ. ֆայլի անունը չի
+// կարող դուրս գալ պանակից, այդ թվում՝ «..»-ով կամ սիմվոլիկ հղումներով։
+// Այն փակում է Machine.Close-ը, ուստի ամեն մեքենայի համար պետք է
+// ստեղծել առանձին ֆայլային համակարգ
+func DirFS(dir string) (FileSystem, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return rootFS{root}, nil
+}
+
+type rootFS struct {
+	root *os.Root
+}
+
+func (r rootFS) Close() error {
+	return r.root.Close()
+}
+
+func (r rootFS) OpenFile(name string, flag int) (File, error) {
+	if !filepath.IsLocal(name) {
+		return nil, fs.ErrPermission
+	}
+	return r.root.OpenFile(name, flag, 0644)
+}
+
+// միայն կարդալու համար նախատեսված ֆայլային համակարգ, օրինակ՝
+// fstest.MapFS թեստերի համար
+func ReadOnlyFS(fsys fs.FS) FileSystem {
+	return readOnlyFS{fsys}
+}
+
+type readOnlyFS struct {
+	fsys fs.FS
+}
+
+func (r readOnlyFS) OpenFile(name string, flag int) (File, error) {
+	if flag != os.O_RDONLY {
+		return nil, fs.ErrPermission
+	}
+	file, err := r.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return readOnlyFile{file}, nil
+}
+
+type readOnlyFile struct {
+	fs.File
+}
+
+func (readOnlyFile) Write([]byte) (int, error) {
+	return 0, fs.ErrPermission
+}
+
+// փակել ծրագրի բացած բոլոր ֆայլերը, ինչպես նաև ֆայլային համակարգը,
+// եթե այն io.Closer է
+func (m *Machine) Close() error {
+	var errs []error
+	for handle, file := range m.openFiles {
+		errs = append(errs, file.Close())
+		delete(m.openFiles, handle)
+	}
+	if closer, ok := m.files.(io.Closer); ok {
+		errs = append(errs, closer.Close())
+		m.files = nil
+	}
+	return errors.Join(errs...)
+}
+
+// SYS n. կատարել համակարգային կանչը
+func (m *Machine) systemCall() {
+	number := m.readCode(m.ip)
+	m.ip += 4
+	if m.observer != nil {
+		m.observer.IO(IOSystemCall, number)
+	}
+
+	switch number {
+	case SysOpen:
+		mode := m.basicPop()
+		path := m.basicPop()
+		m.basicPush(m.openFile(path, mode))
+	case SysRead:
+		length := m.basicPop()
+		buffer := m.block(length)
+		handle := m.basicPop()
+		m.basicPush(m.readFile(handle, buffer, length))
+	case SysWrite:
+		length := m.basicPop()
+		buffer := m.block(length)
+		handle := m.basicPop()
+		m.basicPush(m.writeFile(handle, buffer, length))
+	case SysClose:
+		handle := m.basicPop()
+		m.basicPush(m.closeFile(handle))
+	default:
+		m.trap(TrapInvalidSystemCall, number)
+	}
+}
+
+func (m *Machine) openFile(path int32, mode int32) int32 {
+	var flag int
+	switch mode {
+	case OpenRead:
+		flag = os.O_RDONLY
+	case OpenWrite:
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	case OpenAppend:
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	default:
+		return SysErrInvalid
+	}
+
+	name, ok := m.readString(path)
+	if !ok {
+		return SysErrInvalid
+	}
+	if m.files == nil {
+		return SysErrPermission
+	}
+	if len(m.openFiles) >= maxOpenFiles {
+		return SysErrTooManyFiles
+	}
+
+	file, err := m.files.OpenFile(name, flag)
+	if err != nil {
+		return errorCode(err)
+	}
+
+	if m.openFiles == nil {
+		m.openFiles = make(map[int32]File)
+	}
+	handle := int32(3)
+	for m.openFiles[handle] != nil {
+		handle++
+	}
+	m.openFiles[handle] = file
+	return handle
+}
+
+func (m *Machine) readFile(handle int32, buffer int16, length int32) int32 {
+	var reader io.Reader
+	switch handle {
+	case 0:
+		reader = m.stdin
+	case 1, 2:
+		return SysErrPermission
+	default:
+		reader = m.openFiles[handle]
+	}
+	if reader == nil {
+		return SysErrBadFile
+	}
+
+	// կարդացված մուտքը չպետք է կորչի բուֆերի էջային խափանումից
+	m.probe(int32(buffer), length, accessWrite)
+	data := make([]byte, length)
+	count, err := reader.Read(data)
+	if err != nil && err != io.EOF {
+		return errorCode(err)
+	}
+	for i, b := range data[:count] {
+		m.writeByte(buffer+int16(i), b)
+	}
+	return int32(count)
+}
+
+func (m *Machine) writeFile(handle int32, buffer int16, length int32) int32 {
+	var writer io.Writer
+	switch handle {
+	case 0:
+		return SysErrPermission
+	case 1:
+		writer = m.stdout
+	case 2:
+		writer = m.stderr
+	default:
+		writer = m.openFiles[handle]
+	}
+	if writer == nil {
+		return SysErrBadFile
+	}
+
+	data := make([]byte, length)
+	for i := range data {
+		data[i] = m.readByte(buffer + int16(i))
+	}
+	count, err := writer.Write(data)
+	if err != nil {
+		return errorCode(err)
+	}
+	return int32(count)
+}
+
+func (m *Machine) closeFile(handle int32) int32 {
+	file := m.openFiles[handle]
+	if file == nil {
+		return SysErrBadFile
+	}
+	delete(m.openFiles, handle)
+	if err := file.Close(); err != nil {
+		return errorCode(err)
+	}
+	return 0
+}
+
+// կարդալ address հասցեից սկսվող զրոյով ավարտվող տողը
+func (m *Machine) readString(address int32) (string, bool) {
+	address = m.untag(address)
+	text := []byte{}
+	for i := range int32(maxPathLength + 1) {
+		if address < 0 || address+i >= m.addressSpace() {
+			return "", false
+		}
+		b := m.readByte(int16(address + i))
+		if b == 0 {
+			return string(text), true
+		}
+		text = append(text, b)
+	}
+	return "", false
+}
+
+// Go-ի սխալը վերածել համակարգային կանչի սխալի կոդի
+func errorCode(err error) int32 {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return SysErrNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return SysErrPermission
+	case errors.Is(err, fs.ErrInvalid):
+		return SysErrInvalid
+	}
+	return SysErrIO
+}
@@ -0,0 +1,177 @@
+package machine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"svm/bytecode"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	pathAddress   = 8000
+	bufferAddress = 9000
+)
+
+// ծրագիր, որը բացում է pathAddress հասցեում գրված ֆայլը mode ռեժիմով,
+// և կատարում է operation կանչը bufferAddress հասցեի length բայթերով
+func fileProgram(mode, operation, length int32) []byte {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, pathAddress)
+	builder.AddWithNumeric(bytecode.Push, mode)
+	builder.AddWithNumeric(bytecode.Sys, SysOpen)
+	builder.AddWithAddress(bytecode.Push, bytecode.StackPointer, -4)
+	builder.AddWithNumeric(bytecode.Push, bufferAddress)
+	builder.AddWithNumeric(bytecode.Push, length)
+	builder.AddWithNumeric(bytecode.Sys, operation)
+	builder.AddWithAddress(bytecode.Push, bytecode.StackPointer, -8)
+	builder.AddWithNumeric(bytecode.Sys, SysClose)
+	builder.AddBasic(bytecode.Halt)
+	return builder.Bytes()
+}
+
+func runFileProgram(t *testing.T, m *Machine, path string, program []byte) (int32, int32, int32) {
+	t.Helper()
+	copy(m.memory[pathAddress:], path+"\x00")
+	m.Load(program)
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	closed := m.basicPop()
+	count := m.basicPop()
+	handle := m.basicPop()
+	return handle, count, closed
+}
+
+func TestReadOnlyFileSystem(t *testing.T) {
+	fsys := fstest.MapFS{"data/input.txt": {Data: []byte("hello, svm")}}
+
+	m := NewMachine(WithFileSystem(ReadOnlyFS(fsys)))
+	handle, count, closed := runFileProgram(t, m, "data/input.txt", fileProgram(OpenRead, SysRead, 100))
+	if handle != 3 || count != 10 || closed != 0 {
+		t.Errorf("Սպասվում է 3, 10, 0, ստացվել է %d, %d, %d", handle, count, closed)
+	}
+	if s := string(m.memory[bufferAddress : bufferAddress+10]); s != "hello, svm" {
+		t.Errorf("Սպասվում է \"hello, svm\", ստացվել է %q", s)
+	}
+
+	m = NewMachine(WithFileSystem(ReadOnlyFS(fsys)))
+	handle, _, closed = runFileProgram(t, m, "missing.txt", fileProgram(OpenRead, SysRead, 100))
+	if handle != SysErrNotFound || closed != SysErrBadFile {
+		t.Errorf("Սպասվում է %d, %d, ստացվել է %d, %d", SysErrNotFound, SysErrBadFile, handle, closed)
+	}
+
+	m = NewMachine(WithFileSystem(ReadOnlyFS(fsys)))
+	handle, _, _ = runFileProgram(t, m, "data/input.txt", fileProgram(OpenWrite, SysWrite, 1))
+	if handle != SysErrPermission {
+		t.Errorf("Սպասվում է %d, ստացվել է %d", SysErrPermission, handle)
+	}
+}
+
+func TestDirectoryFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	fsys, err := DirFS(dir)
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	for _, mode := range []int32{OpenWrite, OpenAppend, OpenAppend} {
+		m := NewMachine(WithFileSystem(fsys))
+		copy(m.memory[bufferAddress:], "abc")
+		_, count, closed := runFileProgram(t, m, "out.txt", fileProgram(mode, SysWrite, 3))
+		if count != 3 || closed != 0 {
+			t.Errorf("Սպասվում է 3, 0, ստացվել է %d, %d", count, closed)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil || string(data) != "abcabcabc" {
+		t.Errorf("Սպասվում է \"abcabcabc\", ստացվել է %q (%v)", data, err)
+	}
+
+	m := NewMachine(WithFileSystem(fsys))
+	handle, _, _ := runFileProgram(t, m, "../escape.txt", fileProgram(OpenWrite, SysWrite, 3))
+	if handle != SysErrPermission {
+		t.Errorf("Պանակից դուրս ֆայլի համար սպասվում է %d, ստացվել է %d", SysErrPermission, handle)
+	}
+}
+
+func TestCloseDirectoryFileSystem(t *testing.T) {
+	fsys, err := DirFS(t.TempDir())
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	m := NewMachine(WithFileSystem(fsys))
+	if err := m.Close(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if _, err := fsys.OpenFile("out.txt", os.O_WRONLY|os.O_CREATE); err == nil {
+		t.Errorf("Մեքենան փակելուց հետո պանակը պետք է փակված լինի")
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("Կրկնակի Close-ի համար սխալ չի սպասվում. %v", err)
+	}
+}
+
+func TestHeapPath(t *testing.T) {
+	fsys := fstest.MapFS{"input.txt": {Data: []byte("heap")}}
+	m := NewMachine(WithFileSystem(ReadOnlyFS(fsys)), WithHeap(1024))
+	// ֆայլի անունը կույտի օբյեկտում է, իսկ դրա հասցեն՝ PointerTag-ով
+	path := int32(m.heap + headerBytes)
+	copy(m.memory[path:], "input.txt\x00")
+	m.Load(fileProgram(OpenRead, SysRead, 100))
+	// առաջին PUSH-ի արգումենտը փոխարինել նշված ցուցիչով
+	m.WriteMemory(1, binary.LittleEndian.AppendUint32(nil, uint32(PointerTag|path)))
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	m.basicPop()
+	if count := m.basicPop(); count != 4 {
+		t.Errorf("Սպասվում է 4 բայթ, բայց ստացվել է %d", count)
+	}
+}
+
+func TestStandardFiles(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddWithNumeric(bytecode.Push, bufferAddress)
+	builder.AddWithNumeric(bytecode.Push, 2)
+	builder.AddWithNumeric(bytecode.Sys, SysWrite)
+	builder.AddWithNumeric(bytecode.Push, 7)
+	builder.AddWithNumeric(bytecode.Sys, SysClose)
+	builder.AddBasic(bytecode.Halt)
+
+	stdout := bytes.NewBufferString("")
+	m := NewMachine(WithStdout(stdout))
+	copy(m.memory[bufferAddress:], "ok")
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if stdout.String() != "ok" {
+		t.Errorf("Սպասվում է \"ok\", ստացվել է %q", stdout.String())
+	}
+	if closed, written := m.basicPop(), m.basicPop(); closed != SysErrBadFile || written != 2 {
+		t.Errorf("Սպասվում է %d, 2, ստացվել է %d, %d", SysErrBadFile, closed, written)
+	}
+
+	// առանց ֆայլային համակարգի ֆայլեր բացել հնարավոր չէ
+	m = NewMachine()
+	handle, _, _ := runFileProgram(t, m, "any.txt", fileProgram(OpenRead, SysRead, 1))
+	if handle != SysErrPermission {
+		t.Errorf("Սպասվում է %d, ստացվել է %d", SysErrPermission, handle)
+	}
+}
+
+func TestInvalidSystemCall(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Sys, 99)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	if trap, ok := m.Run().(*Trap); !ok || trap.Code != TrapInvalidSystemCall {
+		t.Errorf("Սպասվում է TrapInvalidSystemCall")
+	}
+}
@@ -0,0 +1,50 @@
+package machine
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"svm/bytecode"
+	"testing"
+)
+
+// քայլերի սահմանը մեկ կատարման համար
+const fuzzSteps = 10000
+
+func FuzzMachine(f *testing.F) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithLabel(bytecode.Call, "main")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("main")
+	builder.AddBasic(bytecode.Input)
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddBasic(bytecode.Div)
+	builder.AddBasic(bytecode.Print)
+	builder.AddWithNumeric(bytecode.Ret, 0)
+	builder.Validate()
+	f.Add(builder.Bytes(), false)
+	f.Add(builder.Bytes(), true)
+	f.Add(racyIncrement(), false)
+	f.Add([]byte{}, false)
+
+	f.Fuzz(func(t *testing.T, code []byte, harvard bool) {
+		options := []Option{
+			WithStdin(strings.NewReader("1 2 3")),
+			WithStdout(io.Discard),
+			WithStderr(io.Discard),
+			WithStepLimit(fuzzSteps),
+		}
+		if harvard {
+			options = append(options, WithHarvard())
+		}
+		m := NewMachine(options...)
+		defer m.Close()
+		m.Load(code)
+		err := m.Run()
+		var trap *Trap
+		if err != nil && !errors.As(err, &trap) {
+			t.Fatalf("Սպասվում է ծուղակ, բայց ստացվել է %v", err)
+		}
+		m.Backtrace()
+	})
+}
@@ -0,0 +1,51 @@
+package machine
+
+import (
+	"encoding/binary"
+	"svm/bytecode"
+)
+
+// Հարվարդյան ռեժիմում ծրագիրը բեռնվում է առանձին՝ միայն կարդալու
+// համար նախատեսված հրամանների հիշողության մեջ, իսկ մեքենայի հիշողությունը
+// պարունակում է միայն տվյալներ։ Հրամանները, դրանց արգումենտներն ու
+// անցումների աղյուսակները կարդացվում են հրամանների հիշողությունից,
+// IP-ի նկատմամբ հարաբերական PUSH-ը նույնպես կարդում է հրամանների
+// հիշողությունից, իսկ մնացած բոլոր դիմումները (ստեկը, LOAD/STORE-ը,
+// FP-ի և SP-ի նկատմամբ հարաբերական հասցեները) տվյալների հիշողությանն
+// են։ Ստեկը սկսվում է տվյալների հիշողության 0 հասցեից։
+
+// ծրագիրը բեռնել տվյալներից առանձին հրամանների հիշողության մեջ
+func WithHarvard() Option {
+	return func(m *Machine) {
+		m.harvard = true
+	}
+}
+
+// addr հասցեից կարդալ հրամանի len(buffer) բայթ
+func (m *Machine) codeBytes(addr int32, buffer []byte) {
+	if !m.harvard {
+		m.readBytes(addr, buffer, accessFetch)
+		return
+	}
+	if addr < 0 || addr > int32(len(m.code)-len(buffer)) {
+		m.trap(TrapMemoryBounds, addr)
+	}
+	copy(buffer, m.code[addr:])
+}
+
+func (m *Machine) readCodeWord(addr int16) uint16 {
+	var value [2]byte
+	m.codeBytes(int32(addr), value[:])
+	return binary.LittleEndian.Uint16(value[:])
+}
+
+func (m *Machine) readCode(addr int16) int32 {
+	var value [4]byte
+	m.codeBytes(int32(addr), value[:])
+	return int32(binary.LittleEndian.Uint32(value[:]))
+}
+
+// արդյոք relative անուղղակի հասցեն հրամանների հիշողությանն է
+func (m *Machine) codeRelative(relative uint16) bool {
+	return m.harvard && relative&0xC000 == bytecode.InstructionPointer
+}
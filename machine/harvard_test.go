@@ -0,0 +1,71 @@
+package machine
+
+import (
+	"bytes"
+	"errors"
+	"svm/bytecode"
+	"testing"
+)
+
+func TestHarvardCodeIsolation(t *testing.T) {
+	// ծրագիրը PRINT-ի տեղում գրում է անծանոթ գործողությունների կոդեր
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 0x3F3F3F3F)
+	builder.AddLabelAddress(bytecode.Push, "print")
+	builder.AddBasic(bytecode.Store)
+	builder.AddWithNumeric(bytecode.Push, 42)
+	builder.SetLabel("print")
+	builder.AddBasic(bytecode.Print)
+	builder.AddBasic(bytecode.Halt)
+	builder.Validate()
+
+	m := NewMachine(WithSelfModifyingCode(), WithStdout(bytes.NewBufferString("")))
+	m.Load(builder.Bytes())
+	var trap *Trap
+	if err := m.Run(); !errors.As(err, &trap) || trap.Code != TrapInvalidOpcode {
+		t.Errorf("Սպասվում է անծանոթ գործողության կոդ, բայց ստացվել է %v", err)
+	}
+
+	stdout := bytes.NewBufferString("")
+	m = NewMachine(WithHarvard(), WithStdout(stdout))
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if stdout.String() != "42\n" {
+		t.Errorf("Սպասվում է \"42\\n\", բայց ստացվել է %q", stdout.String())
+	}
+	address := int16(builder.Symbols()["print"])
+	if v := m.read(address); v != 0x3F3F3F3F {
+		t.Errorf("Տվյալների հիշողությունում սպասվում է %d, բայց ստացվել է %d", 0x3F3F3F3F, v)
+	}
+}
+
+func TestHarvardInstructionPointer(t *testing.T) {
+	// PUSH [IP+0]-ն կարդում է հաջորդ հրամանի կոդը
+	builder := bytecode.NewBuilder()
+	builder.AddWithAddress(bytecode.Push, bytecode.InstructionPointer, 0)
+	builder.AddWithNumeric(bytecode.Push, 7)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine(WithHarvard())
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if m.sp != 8 || m.read(0) != 0x741 || m.read(4) != 7 {
+		t.Errorf("Ստեկը պետք է սկսվի 0 հասցեից և պարունակի %d և 7", 0x741)
+	}
+
+	builder = bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 7)
+	builder.AddWithAddress(bytecode.Pop, bytecode.InstructionPointer, -7)
+	builder.AddBasic(bytecode.Halt)
+
+	m = NewMachine(WithHarvard())
+	m.Load(builder.Bytes())
+	var trap *Trap
+	if err := m.Run(); !errors.As(err, &trap) || trap.Code != TrapWriteProtected {
+		t.Errorf("Հրամանների հիշողությունում գրելը պետք է կանգնեցնի մեքենան, բայց ստացվել է %v", err)
+	}
+}
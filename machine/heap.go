@@ -0,0 +1,227 @@
+package machine
+
+import "svm/bytecode"
+
+// Կառավարվող կույտը զբաղեցնում է հիշողության վերջին մասը։ Կույտը
+// բաժանված է բլոկների, ամեն մի բլոկ սկսվում է մեկ բառանոց գլխագրով,
+// որին հաջորդում են բլոկի սլոտները (ամեն մեկը 4 բայթ)։ Գլխագրի ցածր
+// 16 բիթերում գրված է սլոտների քանակը, իսկ դրանից բարձր բիթերում՝
+// բլոկի զբաղված լինելու և նշված լինելու դրոշակները։
+//
+// NEW հրամանը ստեկում գրում է օբյեկտի առաջին սլոտի հասցեն՝ նշված
+// PointerTag-ով։ Այդ նշանով են աղբահավաքն ու LOAD/STORE հրամանները
+// տարբերում ցուցիչներն ամբողջ թվերից։
+
+// կույտի օբյեկտի ցուցիչի նշանը. ցուցիչի բարձր 16 բիթերը
+const PointerTag int32 = 0x40000000
+
+const (
+	slotsMask   int32 = 0xFFFF  // սլոտների քանակը
+	usedFlag    int32 = 1 << 16 // բլոկը զբաղված է
+	markedFlag  int32 = 1 << 17 // բլոկը նշված է աղբահավաքի կողմից
+	headerBytes int16 = 4
+)
+
+// կույտի և աղբահավաքի վիճակագրությունը
+type HeapStats struct {
+	Collections int // աղբահավաքի աշխատանքների քանակը
+	Allocated   int // ստեղծված օբյեկտների քանակը
+	Freed       int // աղբահավաքի ազատած օբյեկտների քանակը
+	LiveObjects int // կույտում առկա օբյեկտների քանակը
+	LiveBytes   int // առկա օբյեկտների զբաղեցրած բայթերը՝ գլխագրերով
+	FreeBytes   int // ազատ բայթերը
+}
+
+// հիշողության վերջին size բայթերում ստեղծել կառավարվող կույտ. size-ը
+// պետք է լինի 8-ից մինչև հիշողության կեսը, հակառակ դեպքում կույտ չի ստեղծվում
+func WithHeap(size int) Option {
+	return func(m *Machine) {
+		size -= size % 4
+		if size < 8 || size > MemorySize/2 {
+			return
+		}
+		m.heap = int16(MemorySize - size)
+		m.limit = m.heap
+		m.write(m.heap, int32(size)/4-1)
+	}
+}
+
+// կույտի վիճակագրությունը. ծրագիրը կարող է փչացնել բլոկների
+// գլխագրերը, որի դեպքում վերադարձվում է ծուղակը
+func (m *Machine) HeapStats() (HeapStats, error) {
+	defer m.untracked()()
+	defer m.inspect()()
+	stats := m.heapStats
+	err := m.protect(func() {
+		for block := range m.blocks() {
+			header := m.read(block)
+			size := int(headerBytes) + 4*int(header&slotsMask)
+			if header&usedFlag != 0 {
+				stats.LiveObjects++
+				stats.LiveBytes += size
+			} else {
+				stats.FreeBytes += size
+			}
+		}
+	})
+	if err != nil {
+		return HeapStats{}, err
+	}
+	return stats, nil
+}
+
+// կույտի բլոկների գլխագրերի հասցեները. հիշողության սահմաններից դուրս
+// եկող բլոկի դեպքում առաջանում է TrapMemoryBounds ծուղակը
+func (m *Machine) blocks() func(func(int16) bool) {
+	return func(yield func(int16) bool) {
+		if m.heap == 0 {
+			return
+		}
+		// int16-ով բլոկի չափը կարող է գերլցվել և դառնալ 0
+		for block := int32(m.heap); block < MemorySize; {
+			size := int32(headerBytes) + 4*(m.read(int16(block))&slotsMask)
+			if size <= 0 || block+size > MemorySize {
+				m.trap(TrapMemoryBounds, block)
+			}
+			if !yield(int16(block)) {
+				return
+			}
+			block += size
+		}
+	}
+}
+
+// NEW կամ NEW n. ստեղծել n սլոտով օբյեկտ
+func (m *Machine) allocate(mode byte) {
+	var slots int32
+	if mode == bytecode.Immediate {
+		slots = m.readCode(m.ip)
+		m.ip += 4
+	} else {
+		slots = m.basicPop()
+	}
+	if m.heap == 0 || slots < 0 || slots > slotsMask {
+		m.trap(TrapOutOfMemory, slots)
+	}
+
+	block, found := m.findFree(slots)
+	if !found {
+		m.collect()
+		block, found = m.findFree(slots)
+	}
+	if !found {
+		m.trap(TrapOutOfMemory, slots)
+	}
+
+	// բլոկի ավելցուկը դարձնել առանձին ազատ բլոկ
+	available := m.read(block) & slotsMask
+	if available > slots {
+		m.write(block+headerBytes+4*int16(slots), available-slots-1)
+	}
+	m.write(block, slots|usedFlag)
+	for i := range int16(slots) {
+		m.write(block+headerBytes+4*i, 0)
+	}
+	m.heapStats.Allocated++
+
+	m.basicPush(PointerTag | int32(block+headerBytes))
+}
+
+// գտնել առնվազն slots սլոտ ունեցող առաջին ազատ բլոկը
+func (m *Machine) findFree(slots int32) (int16, bool) {
+	for block := range m.blocks() {
+		header := m.read(block)
+		if header&usedFlag == 0 && header&slotsMask >= slots {
+			return block, true
+		}
+	}
+	return 0, false
+}
+
+// նշել-մաքրել աղբահավաքը
+func (m *Machine) collect() {
+	if m.heap == 0 {
+		return
+	}
+	m.heapStats.Collections++
+	defer m.untracked()()
+
+	// կույտի օբյեկտներն ըստ դրանց առաջին սլոտի հասցեի
+	objects := map[int32]int16{}
+	for block := range m.blocks() {
+		if m.read(block)&usedFlag != 0 {
+			objects[PointerTag|int32(block+headerBytes)] = block
+		}
+	}
+
+	// նշել արմատներից հասանելի օբյեկտները
+	pending := []int16{}
+	visit := func(value int32) {
+		block, ok := objects[value]
+		if !ok {
+			return
+		}
+		header := m.read(block)
+		if header&markedFlag == 0 {
+			m.write(block, header|markedFlag)
+			pending = append(pending, block)
+		}
+	}
+	for _, value := range m.roots() {
+		visit(value)
+	}
+	for len(pending) > 0 {
+		block := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for i := range int16(m.read(block) & slotsMask) {
+			visit(m.read(block + headerBytes + 4*i))
+		}
+	}
+
+	// ազատել չնշված օբյեկտները և միավորել հարևան ազատ բլոկները
+	previous := int16(-1)
+	for block := range m.blocks() {
+		header := m.read(block)
+		switch {
+		case header&markedFlag != 0:
+			m.write(block, header&^markedFlag)
+			previous = -1
+			continue
+		case header&usedFlag != 0:
+			m.heapStats.Freed++
+			header &^= usedFlag
+			m.write(block, header)
+		}
+		if previous != -1 {
+			merged := m.read(previous) + 1 + header&slotsMask
+			m.write(previous, merged)
+			continue
+		}
+		previous = block
+	}
+}
+
+// աղբահավաքի արմատները՝ բոլոր հոսքերի ստեկների բոլոր բառերը, բացի
+// կանչերի կադրերի վերադարձի հասցեներից ու պահված FP-երից, որոնք
+// գտնվում են FP-երի շղթայով
+func (m *Machine) roots() []int32 {
+	values := []int32{}
+	for _, stack := range m.stacks() {
+		base, sp, fp := stack[0], stack[1], stack[2]
+		bookkeeping := map[int16]bool{}
+		for frame := fp; frame >= base+8 && frame <= sp; frame = int16(m.read(frame - 4)) {
+			if bookkeeping[frame-4] {
+				break
+			}
+			bookkeeping[frame-8] = true
+			bookkeeping[frame-4] = true
+		}
+
+		for address := base; address+4 <= sp; address += 4 {
+			if !bookkeeping[address] {
+				values = append(values, m.read(address))
+			}
+		}
+	}
+	return values
+}
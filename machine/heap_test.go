@@ -0,0 +1,163 @@
+package machine
+
+import (
+	"errors"
+	"svm/bytecode"
+	"testing"
+)
+
+func TestGarbageCollection(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	// p1 = NEW 2, p2 = NEW 3, p1[0] = p2
+	builder.AddWithNumeric(bytecode.New, 2)
+	builder.AddWithNumeric(bytecode.New, 3)
+	builder.AddWithAddress(bytecode.Push, bytecode.StackPointer, -4)
+	builder.AddWithAddress(bytecode.Push, bytecode.StackPointer, -12)
+	builder.AddBasic(bytecode.Store)
+	builder.AddWithAddress(bytecode.Pop, bytecode.StackPointer, -4)
+	// անհասանելի օբյեկտ
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.New)
+	builder.AddWithAddress(bytecode.Pop, bytecode.StackPointer, -4)
+	builder.AddBasic(bytecode.GC)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine(WithHeap(1024))
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	stats, err := m.HeapStats()
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if stats.Allocated != 3 || stats.Freed != 1 || stats.LiveObjects != 2 {
+		t.Errorf("Սխալ վիճակագրություն. %+v", stats)
+	}
+	if stats.LiveBytes != 12+16 || stats.LiveBytes+stats.FreeBytes != 1024 {
+		t.Errorf("Սխալ վիճակագրություն. %+v", stats)
+	}
+
+	// p1-ը հեռացնելուց հետո կույտն ամբողջությամբ ազատ է
+	m.basicPop()
+	m.collect()
+	stats, _ = m.HeapStats()
+	if stats.Freed != 3 || stats.LiveObjects != 0 || stats.FreeBytes != 1024 {
+		t.Errorf("Սխալ վիճակագրություն. %+v", stats)
+	}
+	if header := m.read(m.heap); header != 1024/4-1 {
+		t.Errorf("Ազատ բլոկները պետք է միավորվեն, ստացվել է %#x", header)
+	}
+}
+
+func TestGarbageCollectionRoots(t *testing.T) {
+	// օբյեկտը հասանելի է միայն ենթածրագրի արգումենտից
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.New, 1)
+	builder.AddWithLabel(bytecode.Call, "f")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("f")
+	builder.AddWithNumeric(bytecode.Enter, 1)
+	builder.AddBasic(bytecode.GC)
+	builder.AddWithNumeric(bytecode.Push, 77)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12)
+	builder.AddBasic(bytecode.Store)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12)
+	builder.AddBasic(bytecode.Load)
+	builder.AddWithNumeric(bytecode.Ret, 1)
+	builder.Validate()
+
+	m := NewMachine(WithHeap(64))
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if v := m.basicPop(); v != 77 {
+		t.Errorf("Սպասվում է 77, բայց ստացվել է %d", v)
+	}
+	if stats, _ := m.HeapStats(); stats.Freed != 0 || stats.Collections != 1 {
+		t.Errorf("Սխալ վիճակագրություն. %+v", stats)
+	}
+}
+
+func TestOutOfMemory(t *testing.T) {
+	// անհասանելի օբյեկտները հավաքվում են, և տեղը բավարարում է
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 100)
+	builder.SetLabel("loop")
+	builder.AddWithNumeric(bytecode.New, 10)
+	builder.AddWithAddress(bytecode.Pop, bytecode.StackPointer, -4)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.Sub)
+	builder.AddWithAddress(bytecode.Push, bytecode.StackPointer, -4)
+	builder.AddWithLabel(bytecode.Jz, "end")
+	builder.AddWithLabel(bytecode.Jump, "loop")
+	builder.SetLabel("end")
+	builder.AddBasic(bytecode.Halt)
+	builder.Validate()
+
+	m := NewMachine(WithHeap(128))
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if stats, _ := m.HeapStats(); stats.Allocated != 100 || stats.Collections == 0 {
+		t.Errorf("Սխալ վիճակագրություն. %+v", stats)
+	}
+
+	// հասանելի օբյեկտները չեն տեղավորվում
+	builder = bytecode.NewBuilder()
+	builder.SetLabel("loop")
+	builder.AddWithNumeric(bytecode.New, 10)
+	builder.AddWithLabel(bytecode.Jump, "loop")
+	builder.Validate()
+
+	m = NewMachine(WithHeap(128))
+	m.Load(builder.Bytes())
+	if trap, ok := m.Run().(*Trap); !ok || trap.Code != TrapOutOfMemory {
+		t.Errorf("Սպասվում է TrapOutOfMemory")
+	}
+
+	// առանց կույտի NEW-ը հնարավոր չէ
+	m = NewMachine()
+	m.Load(builder.Bytes())
+	if trap, ok := m.Run().(*Trap); !ok || trap.Code != TrapOutOfMemory {
+		t.Errorf("Սպասվում է TrapOutOfMemory")
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.SetLabel("loop")
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddWithLabel(bytecode.Jump, "loop")
+	builder.Validate()
+
+	m := NewMachine(WithHeap(1024))
+	m.Load(builder.Bytes())
+	if trap, ok := m.Run().(*Trap); !ok || trap.Code != TrapStackOverflow {
+		t.Errorf("Սպասվում է TrapStackOverflow")
+	}
+	if m.sp > m.heap {
+		t.Errorf("Ստեկը չպետք է մտնի կույտի տիրույթ")
+	}
+}
+
+func TestCorruptedHeap(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddBasic(bytecode.GC)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine(WithHeap(1024), WithStepLimit(100))
+	m.Load(builder.Bytes())
+	// բլոկի չափը int16-ով գերլցվում է և դառնում 0
+	m.write(m.heap, 0x3FFF|usedFlag)
+	var trap *Trap
+	if err := m.Run(); !errors.As(err, &trap) || trap.Code != TrapMemoryBounds {
+		t.Errorf("Սպասվում է դիմում հիշողության սահմաններից դուրս, բայց ստացվել է %v", err)
+	}
+	if _, err := m.HeapStats(); err == nil {
+		t.Errorf("Սպասվում է սխալ")
+	}
+}
@@ -1,8 +1,14 @@
 package machine
 
 import (
+	"bufio"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
 	"svm/bytecode"
 )
 
@@ -14,36 +20,199 @@ type Machine struct {
 	ip     int16  // հրամանների ցուցիչ (հաշվիչ)
 	sp     int16  // ստեկի գագաթի ցուցիչ
 	fp     int16  // կանչի ակտիվացման կադրի ցուցիչ
+	hp     int16  // բացառությունների վերջին մշակիչի ցուցիչ
+
+	strict bool // ADD, SUB, MUL, NEG, DIV, MOD հրամանները ստուգում են գերլցումը
+
+	harvard bool   // ծրագիրը բեռնվում է առանձին հրամանների հիշողության մեջ
+	code    []byte // հրամանների հիշողությունը հարվարդյան ռեժիմում
+
+	protected     []bool // միայն կարդալու համար նախատեսված բայթերը
+	selfModifying bool   // ծրագրի հրամանները պաշտպանված չեն
+
+	base      int16     // ստեկի սկիզբը
+	limit     int16     // ստեկի սահմանը
+	heap      int16     // կառավարվող կույտի սկիզբը, 0՝ եթե կույտ չկա
+	heapStats HeapStats // կույտի վիճակագրությունը
+
+	stdin  *bufio.Reader // INPUT-ի աղբյուրը
+	stdout io.Writer     // PRINT-ի արդյունքը
+	stderr io.Writer     // EPRINT-ի արդյունքը
+	status int32         // HALT n-ի ավարտի կոդը
+
+	files     FileSystem     // ծրագրին հասանելի ֆայլային համակարգը
+	openFiles map[int32]File // բաց ֆայլերն ըստ իրենց համարների
+
+	control    [controlRegisters]int32 // կառավարման ռեգիստրները
+	tlb        map[int32]int32         // էջերի աղյուսակի վերջին օգտագործված գրառումները
+	tlbStats   TLBStats                // TLB-ի վիճակագրությունը
+	inspecting bool                    // կարգաբերիչի դիմում, որը չի փոխում TLB-ն և FAULT-ը
+
+	threads []*thread                             // հոսքերը, nil՝ քանի դեռ SPAWN չի կատարվել
+	thread  int                                   // ընթացիկ հոսքի համարը
+	quantum int                                   // հրամանների քանակը, որից հետո հոսքը փոխվում է
+	ticks   int                                   // ընթացիկ հոսքի կատարած հրամանները քվանտում
+	random  *rand.Rand                            // հաջորդ հոսքի պատահական ընտրության աղբյուրը
+	choose  func(runnable []int, current int) int // հաջորդ հոսքի ընտրությունը ամեն քայլից հետո
+	races   *raceDetector                         // մրցավազքերի դետեկտորը
+	atomic  bool                                  // կատարվում է ատոմար դիմում
+
+	observer Observer // կատարման իրադարձությունների դիտորդը
+
+	steps     int // կատարված հրամանների քանակը
+	stepLimit int // հրամանների առավելագույն քանակը, 0՝ առանց սահմանափակման
+
+	program *bytecode.Program // բեռնված ծրագիրը՝ պիտակներով և տողերով
+
+	current int16     // կատարվող հրամանի հասցեն
+	saved   registers // ռեգիստրների արժեքները կատարվող հրամանի սկզբում
 }
 
+// ռեգիստրների արժեքները, որոնք վերականգնվում են էջային խափանումից հետո
+type registers struct {
+	sp, fp, hp int16
+}
+
+// hp-ի արժեքը, երբ բացառությունների մշակիչ չկա
+const noHandler int16 = -1
+
 // ստեղծել նոր մեքենա
-func NewMachine() *Machine {
-	return &Machine{
-		memory: make([]byte, MemorySize),
-		ip:     0,
-		sp:     0,
-		fp:     0,
+func NewMachine(options ...Option) *Machine {
+	m := &Machine{
+		memory:  make([]byte, MemorySize),
+		ip:      0,
+		sp:      0,
+		fp:      0,
+		hp:      noHandler,
+		limit:   MemorySize,
+		stdin:   bufio.NewReader(os.Stdin),
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+		tlb:     map[int32]int32{},
+		quantum: defaultQuantum,
 	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
 }
 
 // ծրագիրը բեռնել հիշողության մեջ
 func (m *Machine) Load(data []byte) {
+	m.program = &bytecode.Program{Code: slices.Clone(data)}
+	if m.harvard {
+		m.code = slices.Clone(data)
+		m.sp = 0 // ստեկը սկսվում է տվյալների հիշողության սկզբից
+		m.base = m.sp
+		return
+	}
 	size := int16(len(data))
 	copy(m.memory, data)
+	m.protected = nil
+	if !m.selfModifying {
+		m.Protect(0, int32(size))
+	}
 	m.sp = size + 1 // ստեկի ցուցիչը դնել ծրագրի ավարտից հետո
+	m.base = m.sp
 }
 
-func (m *Machine) Run() {
-	for m.step() {
+// ծրագրի արգումենտները գրել ստեկում. նախ գրվում են զրոյով ավարտվող
+// տողերը, հետո՝ դրանց հասցեների զանգվածը (argv), իսկ ստեկի գագաթին՝
+// argc-ն և argv-ի հասցեն։ Ծրագրի սկզբի CALL main-ի համար դրանք main-ի
+// արգումենտներն են՝ [FP - 16] և [FP - 12]
+func (m *Machine) SetArguments(args []string) error {
+	size := 4 * int32(len(args)+2)
+	for _, arg := range args {
+		size += int32(len(arg)) + 1
 	}
+	if int32(m.sp)+size+3 > int32(m.limit) {
+		return &Trap{Code: TrapStackOverflow, IP: m.ip, Address: int32(m.sp)}
+	}
+
+	return m.protect(func() {
+		start := m.sp
+		addresses := make([]int32, len(args))
+		for i, arg := range args {
+			addresses[i] = int32(m.sp)
+			for _, b := range []byte(arg + "\x00") {
+				m.writeByte(m.sp, b)
+				m.sp++
+			}
+		}
+		// տողերից հետո ստեկը շարունակել 4-ի պատիկ շեղումից
+		m.sp += (4 - (m.sp-start)%4) % 4
+
+		argv := int32(m.sp)
+		for _, address := range addresses {
+			m.basicPush(address)
+		}
+		m.basicPush(int32(len(args)))
+		m.basicPush(argv)
+	})
+}
+
+// HALT n հրամանով տրված ավարտի կոդը
+func (m *Machine) ExitCode() int32 {
+	return m.status
+}
+
+// կատարել ծրագիրը մինչև HALT հրամանը կամ մինչև սխալը
+func (m *Machine) Run() error {
+	for {
+		running, err := m.execute()
+		if err != nil {
+			return err
+		}
+		if !running {
+			return nil
+		}
+	}
+}
+
+// կատարել մեկ քայլ՝ ծուղակը վերադարձնելով որպես սխալ
+func (m *Machine) execute() (bool, error) {
+	running := false
+	err := m.protect(func() { running = m.step() })
+	if trap, ok := err.(*Trap); ok {
+		if m.observer != nil {
+			m.observer.Trap(trap)
+		}
+		err = m.catch(trap)
+		running = err == nil
+	}
+	if err == nil {
+		running = m.schedule(running)
+		m.current = m.ip
+	}
+	return running, err
+}
+
+// կատարել մեկ հրաման. վերադարձնում է false, եթե ծրագիրն ավարտվել է,
+// իսկ չմշակված ծուղակի դեպքում՝ այն որպես սխալ։ Քայլից հետո
+// Backtrace-ը սկսվում է հաջորդ կատարվող հրամանից
+func (m *Machine) Step() (bool, error) {
+	return m.execute()
 }
 
 // մեքենայի մեկ քայլը
 func (m *Machine) step() bool {
-	command := m.memory[m.ip]
-	m.ip++
+	m.current = m.ip
+	m.saved = registers{m.sp, m.fp, m.hp}
+	if m.stepLimit > 0 && m.steps >= m.stepLimit {
+		m.trap(TrapStepLimit, 0)
+	}
+	m.steps++
+	command := m.fetch()
 	mode := command & 0xC0
 	opcode := command & 0x3F
+	if m.observer != nil {
+		registers := m.Registers()
+		registers.IP = m.current
+		m.observer.Fetch(registers, command)
+	}
+	if privileged(opcode) && m.Mode() == ModeUser {
+		m.trap(TrapPrivileged, int32(opcode))
+	}
 	switch opcode {
 	case bytecode.Nop:
 		// դատարկ հրաման, ոչինչ չանել
@@ -54,31 +223,64 @@ func (m *Machine) step() bool {
 	case bytecode.Call:
 		m.call()
 	case bytecode.Ret:
-		m.ret()
+		m.ret(mode)
 	case bytecode.Jump:
 		m.jump()
 	case bytecode.Jz:
 		m.jz()
+	case bytecode.JumpTab:
+		m.jumpTable()
 	case bytecode.Input:
 		m.input()
 	case bytecode.Print:
 		m.print()
+	case bytecode.EPrint:
+		m.errorPrint()
+	case bytecode.Sys:
+		m.systemCall()
+	case bytecode.GetCR:
+		m.getControl()
+	case bytecode.SetCR:
+		m.setControl()
+	case bytecode.IRet:
+		m.interruptReturn()
+	case bytecode.SysEnter:
+		m.systemEnter()
+	case bytecode.Spawn:
+		m.spawn()
+	case bytecode.Cas:
+		m.compareAndSwap()
+	case bytecode.FetchAdd:
+		m.fetchAndAdd()
+	case bytecode.Fence:
+		m.fence()
+	case bytecode.Assert:
+		m.assert()
 	case bytecode.Halt:
+		m.halt(mode)
 		return false
 	case bytecode.Neg:
-		m.negation()
+		m.negation(m.strict)
+	case bytecode.NegO:
+		m.negation(true)
 	case bytecode.Not:
 		m.not()
 	case bytecode.Add:
-		m.binary(func(a, b int32) int32 { return a + b })
+		m.arithmetic(func(a, b int64) int64 { return a + b }, m.strict)
 	case bytecode.Sub:
-		m.binary(func(a, b int32) int32 { return a - b })
+		m.arithmetic(func(a, b int64) int64 { return a - b }, m.strict)
 	case bytecode.Mul:
-		m.binary(func(a, b int32) int32 { return a * b })
+		m.arithmetic(func(a, b int64) int64 { return a * b }, m.strict)
+	case bytecode.AddO:
+		m.arithmetic(func(a, b int64) int64 { return a + b }, true)
+	case bytecode.SubO:
+		m.arithmetic(func(a, b int64) int64 { return a - b }, true)
+	case bytecode.MulO:
+		m.arithmetic(func(a, b int64) int64 { return a * b }, true)
 	case bytecode.Div:
-		m.binary(func(a, b int32) int32 { return a / b })
+		m.division(func(a, b int32) int32 { return a / b })
 	case bytecode.Mod:
-		m.binary(func(a, b int32) int32 { return a % b })
+		m.division(func(a, b int32) int32 { return a % b })
 	case bytecode.And:
 		m.binary(func(a, b int32) int32 { return a & b })
 	case bytecode.Or:
@@ -95,8 +297,38 @@ func (m *Machine) step() bool {
 		m.comparison(func(a, b int32) bool { return a > b })
 	case bytecode.Ge:
 		m.comparison(func(a, b int32) bool { return a >= b })
+	case bytecode.Load:
+		m.load()
+	case bytecode.Store:
+		m.store()
+	case bytecode.LoadB:
+		m.loadByte()
+	case bytecode.StoreB:
+		m.storeByte()
+	case bytecode.MemCpy:
+		m.memoryCopy()
+	case bytecode.MemSet:
+		m.memorySet()
+	case bytecode.MemCmp:
+		m.memoryCompare()
+	case bytecode.New:
+		m.allocate(mode)
+	case bytecode.GC:
+		m.collect()
+	case bytecode.Enter:
+		m.enter()
+	case bytecode.Leave:
+		m.leave()
+	case bytecode.Try:
+		m.try()
+	case bytecode.EndTry:
+		m.endTry()
+	case bytecode.Throw:
+		m.throw(m.basicPop())
+	case bytecode.TailCall:
+		m.tailCall()
 	default:
-		panic("Սխալ (անծանոթ) գործողության կոդ։")
+		m.trap(TrapInvalidOpcode, int32(command))
 	}
 
 	return true
@@ -106,26 +338,34 @@ func (m *Machine) push(mode byte) {
 	var value int32
 	switch mode {
 	case bytecode.Immediate: // անմիջական արժեք
-		value = m.read(m.ip)
+		value = m.readCode(m.ip)
 		m.ip += 4
 	case bytecode.Indirect: // անուղակի արժեք
 		// հարաբերական հասցեն
-		raddr := m.readWord(m.ip)
+		raddr := m.readCodeWord(m.ip)
 		m.ip += 2
 		// բացարձակ հասցեի հաշվելը
 		address := m.resolveRelativeAddress(raddr)
 		// ստեկում գրելու արժեքը
-		value = m.read(address)
+		if m.codeRelative(raddr) {
+			value = m.readCode(address)
+		} else {
+			value = m.read(address)
+		}
 	}
 	m.basicPush(value)
 }
 
 func (m *Machine) pop() {
 	// POP-ի հարաբերական հասցեն
-	raddr := m.readWord(m.ip)
+	raddr := m.readCodeWord(m.ip)
 	m.ip += 2
 	// հաշվել բացարձակ հասցեն
 	address := m.resolveRelativeAddress(raddr)
+	// հրամանների հիշողությունում գրել հնարավոր չէ
+	if m.codeRelative(raddr) {
+		m.trap(TrapWriteProtected, int32(address))
+	}
 	// վերցնել ստեկի գագաթի արժեքն ...
 	value := m.basicPop()
 	// ... ու գրել որոշված հասցեում
@@ -134,7 +374,7 @@ func (m *Machine) pop() {
 
 func (m *Machine) call() {
 	// CALL-ի արգումենտը (բացարձակ հասցե)
-	address := m.readWord(m.ip)
+	address := m.readCodeWord(m.ip)
 	m.ip += 2
 	// հիշել IP-ը վերադառնալու համար
 	m.basicPush(int32(m.ip))
@@ -144,9 +384,59 @@ func (m *Machine) call() {
 	m.fp = m.sp
 	// շարունակել address-ից
 	m.ip = int16(address)
+	if m.observer != nil {
+		m.observer.Call(m.current, m.ip)
+	}
 }
 
-func (m *Machine) ret() {
+// կանչ, որն օգտագործում է ընթացիկ կադրը. նոր արգումենտները
+// տեղափոխվում են ընթացիկ ենթածրագրի արգումենտների տեղը, իսկ
+// վերադարձի հասցեն ու կանչողի FP-ն մնում են նույնը
+func (m *Machine) tailCall() {
+	// TAILCALL-ի արգումենտը (տես Builder.AddTailCall)
+	operand := uint32(m.readCode(m.ip))
+	m.ip += 4
+	address := int16(operand & 0xFFFF)
+	arguments := int32(operand >> 16 & 0xFF)
+	parameters := int32(operand >> 24)
+
+	// ընթացիկ կադրի վերադարձի հասցեն ու կանչողի FP-ն
+	returnAddress := m.read(m.fp - 8)
+	callerFrame := m.read(m.fp - 4)
+
+	// ընթացիկ ենթածրագրի առաջին արգումենտի ու նոր արգումենտներից
+	// առաջինի հասցեները
+	base := m.address(int32(m.fp)-8-4*parameters, 0)
+	source := m.address(int32(m.sp)-4*arguments, 0)
+
+	// ընթացիկ կադրում գրված մշակիչներն այլևս պետք չեն
+	for m.hp != noHandler && m.hp >= base {
+		m.hp = int16(m.read(m.hp + 8))
+	}
+
+	// արգումենտները տեղափոխել կադրի սկիզբը
+	for i := range arguments {
+		m.write(base+int16(4*i), m.read(source+int16(4*i)))
+	}
+	m.sp = base + int16(4*arguments)
+
+	// նոր կադրը կառուցել այնպես, կարծես կանչը կատարվել է կանչողից
+	m.basicPush(returnAddress)
+	m.basicPush(callerFrame)
+	m.fp = m.sp
+	m.ip = address
+	if m.observer != nil {
+		m.observer.Call(m.current, m.ip)
+	}
+}
+
+func (m *Machine) ret(mode byte) {
+	// RET n տեսքի դեպքում ստեկից հեռացվող արգումենտների քանակը
+	var count int32
+	if mode == bytecode.Immediate {
+		count = m.readCode(m.ip)
+		m.ip += 4
+	}
 	// ֆունկցիայի արժեքը
 	value := m.basicPop()
 	// վերականգնել ստեկի ցուցիչը
@@ -155,20 +445,43 @@ func (m *Machine) ret() {
 	m.fp = int16(m.basicPop())
 	// հաջորդ հրամանի հասցեն
 	m.ip = int16(m.basicPop())
+	// հեռացնել կանչողի փոխանցած արգումենտները
+	m.sp = m.address(int32(m.sp)-4*count, 0)
+	// հեռացված կադրում գրված մշակիչներն այլևս պետք չեն
+	for m.hp != noHandler && m.hp >= m.sp {
+		m.hp = int16(m.read(m.hp + 8))
+	}
 	// ստեկի գագաթին թողնել ֆունկցիայի արժեքը
 	m.basicPush(value)
+	if m.observer != nil {
+		m.observer.Return(m.current, m.ip, value)
+	}
+}
+
+// ստեկում տեղ հատկացնել n լոկալ փոփոխականների համար
+func (m *Machine) enter() {
+	count := m.readCode(m.ip)
+	m.ip += 4
+	for range count {
+		m.basicPush(0)
+	}
+}
+
+// հեռացնել ընթացիկ կադրի լոկալ փոփոխականներն ու միջանկյալ արժեքները
+func (m *Machine) leave() {
+	m.sp = m.fp
 }
 
 func (m *Machine) jump() {
 	// JUMP-ի արգումենտը (բացարձակ հասցե)
-	address := m.readWord(m.ip)
+	address := m.readCodeWord(m.ip)
 	// շարունակել address-ից
 	m.ip = int16(address)
 }
 
 func (m *Machine) jz() {
 	// JUMP-ի արգումենտը (բացարձակ հասցե)
-	address := m.readWord(m.ip)
+	address := m.readCodeWord(m.ip)
 	m.ip += 2
 	// ստեկի գագաթի արժեքը որպես պայման
 	value := m.basicPop()
@@ -177,24 +490,197 @@ func (m *Machine) jz() {
 	}
 }
 
+func (m *Machine) jumpTable() {
+	// JUMPTAB-ի արգումենտը (աղյուսակի բացարձակ հասցե)
+	table := int16(m.readCodeWord(m.ip))
+	m.ip += 2
+	// ստեկի գագաթի արժեքը որպես ինդեքս
+	index := m.basicPop()
+	// աղյուսակում նախ գրված է դեպքերի քանակը, հետո լռելյայն հասցեն
+	count := int32(m.readCodeWord(table))
+	if index < 0 || index >= count {
+		m.ip = int16(m.readCodeWord(table + 2))
+		return
+	}
+	m.ip = int16(m.readCodeWord(table + 4 + 2*int16(index)))
+}
+
 func (m *Machine) input() {
 	// կարդալ նշանով ամբողջ թիվ
 	var value int32
-	fmt.Scanf("%d", &value)
+	fmt.Fscan(m.stdin, &value)
+	if m.observer != nil {
+		m.observer.IO(IOInput, value)
+	}
 	// գրել ստեկում
 	m.basicPush(value)
 }
 
+// ASSERT. ստեկից վերցնել պայմանը և կանգնեցնել մեքենան, եթե այն 0 է
+func (m *Machine) assert() {
+	if m.basicPop() == 0 {
+		m.trap(TrapAssertion, 0)
+	}
+}
+
 func (m *Machine) print() {
 	// վերցնել ստեկի գագաթի արժեքը
 	value := m.basicPop()
 	// ... արտածել այն
-	fmt.Println(value)
+	fmt.Fprintln(m.stdout, value)
+	if m.observer != nil {
+		m.observer.IO(IOPrint, value)
+	}
+}
+
+func (m *Machine) errorPrint() {
+	// վերցնել ստեկի գագաթի արժեքը
+	value := m.basicPop()
+	// ... արտածել այն սխալների հոսքում
+	fmt.Fprintln(m.stderr, value)
+	if m.observer != nil {
+		m.observer.IO(IOErrorPrint, value)
+	}
+}
+
+func (m *Machine) halt(mode byte) {
+	// HALT n տեսքի դեպքում ծրագրի ավարտի կոդը
+	if mode == bytecode.Immediate {
+		m.status = m.readCode(m.ip)
+		m.ip += 4
+	}
 }
 
-// բացասում
-func (m *Machine) negation() {
+// կարդալ ստեկի գագաթին գրված հասցեով բառը
+func (m *Machine) load() {
+	address := m.address(m.basicPop(), 4)
+	m.basicPush(m.read(address))
+}
+
+// ստեկից վերցնել հասցեն ու արժեքը, արժեքը գրել հասցեում
+func (m *Machine) store() {
+	address := m.address(m.basicPop(), 4)
+	value := m.basicPop()
+	m.write(address, value)
+}
+
+// կարդալ ստեկի գագաթին գրված հասցեով բայթը
+func (m *Machine) loadByte() {
+	address := m.address(m.basicPop(), 1)
+	m.basicPush(int32(m.readByte(address)))
+}
+
+// ստեկից վերցնել հասցեն ու արժեքը, արժեքի ցածր բայթը գրել հասցեում
+func (m *Machine) storeByte() {
+	address := m.address(m.basicPop(), 1)
 	value := m.basicPop()
+	m.writeByte(address, byte(value))
+}
+
+// ստեկից վերցնել հիշողության տիրույթի հասցեն ու երկարությունը
+func (m *Machine) block(length int32) int16 {
+	if length < 0 {
+		m.trap(TrapMemoryBounds, length)
+	}
+	return m.address(m.basicPop(), length)
+}
+
+// ստեկից վերցնել նպատակի հասցեն, աղբյուրի հասցեն ու երկարությունը,
+// և պատճենել բայթերը. համընկնող տիրույթները պատճենվում են ճիշտ
+func (m *Machine) memoryCopy() {
+	length := m.basicPop()
+	source := m.block(length)
+	target := m.block(length)
+	// համընկնող տիրույթի մասնակի պատճենումից հետո կրկնված հրամանը
+	// կկարդար արդեն փոխված բայթերը
+	m.probe(int32(source), length, accessRead)
+	m.probe(int32(target), length, accessWrite)
+	if target > source {
+		for i := int16(length) - 1; i >= 0; i-- {
+			m.writeByte(target+i, m.readByte(source+i))
+		}
+		return
+	}
+	for i := range int16(length) {
+		m.writeByte(target+i, m.readByte(source+i))
+	}
+}
+
+// ստեկից վերցնել հասցեն, արժեքն ու երկարությունը, և տիրույթի
+// բոլոր բայթերին վերագրել արժեքի ցածր բայթը
+func (m *Machine) memorySet() {
+	length := m.basicPop()
+	value := byte(m.basicPop())
+	target := m.block(length)
+	for i := range int16(length) {
+		m.writeByte(target+i, value)
+	}
+}
+
+// ստեկից վերցնել երկու տիրույթների հասցեներն ու երկարությունը,
+// և ստեկում գրել -1, 0 կամ 1՝ ըստ բայթ առ բայթ համեմատման արդյունքի
+func (m *Machine) memoryCompare() {
+	length := m.basicPop()
+	right := m.block(length)
+	left := m.block(length)
+	for i := range int16(length) {
+		a, b := m.readByte(left+i), m.readByte(right+i)
+		if a != b {
+			if a < b {
+				m.basicPush(-1)
+			} else {
+				m.basicPush(1)
+			}
+			return
+		}
+	}
+	m.basicPush(0)
+}
+
+// ստեկում գրել բացառությունների մշակիչի գրառումը՝ մշակիչի հասցեն,
+// ընթացիկ FP-ն և նախորդ մշակիչի ցուցիչը
+func (m *Machine) try() {
+	// TRY-ի արգումենտը (մշակիչի բացարձակ հասցե)
+	address := m.readCodeWord(m.ip)
+	m.ip += 2
+	record := m.sp
+	m.basicPush(int32(address))
+	m.basicPush(int32(m.fp))
+	m.basicPush(int32(m.hp))
+	m.hp = record
+}
+
+// հեռացնել վերջին մշակիչի գրառումը
+func (m *Machine) endTry() {
+	if m.hp == noHandler {
+		m.trap(TrapUnhandledException, 0)
+	}
+	m.sp = m.hp
+	m.hp = int16(m.read(m.sp + 8))
+}
+
+// ստեկը փաթաթել մինչև ամենամոտ մշակիչի գրառումը, վերականգնել
+// այդ պահի FP-ն ու կատարումը շարունակել մշակիչից՝ ստեկի գագաթին
+// թողնելով բացառության արժեքը
+func (m *Machine) throw(value int32) {
+	if m.hp == noHandler {
+		panic(&Trap{Code: TrapUnhandledException, IP: m.current, Value: value})
+	}
+	record := m.hp
+	address := m.read(record)
+	m.fp = int16(m.read(record + 4))
+	m.hp = int16(m.read(record + 8))
+	m.sp = record
+	m.ip = int16(address)
+	m.basicPush(value)
+}
+
+// բացասում, checked-ի դեպքում՝ գերլցման ստուգումով
+func (m *Machine) negation(checked bool) {
+	value := m.basicPop()
+	if checked && value == math.MinInt32 {
+		m.trap(TrapOverflow, 0)
+	}
 	m.basicPush(-value)
 }
 
@@ -204,6 +690,18 @@ func (m *Machine) not() {
 	m.basicPush(^value)
 }
 
+// բինար թվաբանական գործողություն. արդյունքը հաշվվում է 64 բիթով,
+// ու checked-ի դեպքում այն պետք է տեղավորվի 32 բիթում
+func (m *Machine) arithmetic(op func(int64, int64) int64, checked bool) {
+	right := m.basicPop()
+	left := m.basicPop()
+	result := op(int64(left), int64(right))
+	if checked && (result < math.MinInt32 || result > math.MaxInt32) {
+		m.trap(TrapOverflow, 0)
+	}
+	m.basicPush(int32(result))
+}
+
 // բինար թվաբանական կամ բիթային գործողություն
 func (m *Machine) binary(op func(int32, int32) int32) {
 	right := m.basicPop()
@@ -212,6 +710,20 @@ func (m *Machine) binary(op func(int32, int32) int32) {
 	m.basicPush(result)
 }
 
+// բաժանման գործողություն՝ զրոյի վրա բաժանման ստուգումով
+func (m *Machine) division(op func(int32, int32) int32) {
+	right := m.basicPop()
+	left := m.basicPop()
+	if right == 0 {
+		m.trap(TrapDivisionByZero, 0)
+	}
+	// MinInt32 / -1-ի արդյունքը չի տեղավորվում 32 բիթում
+	if m.strict && left == math.MinInt32 && right == -1 {
+		m.trap(TrapOverflow, 0)
+	}
+	m.basicPush(op(left, right))
+}
+
 // համեմատման գործողություն
 func (m *Machine) comparison(op func(int32, int32) bool) {
 	right := m.basicPop()
@@ -225,6 +737,9 @@ func (m *Machine) comparison(op func(int32, int32) bool) {
 
 // տարրական ստեկային գործողություն push
 func (m *Machine) basicPush(value int32) {
+	if int32(m.sp)+4 > int32(m.limit) {
+		m.trap(TrapStackOverflow, int32(m.sp))
+	}
 	m.write(m.sp, value)
 	m.sp += 4
 }
@@ -249,14 +764,105 @@ func (m *Machine) resolveRelativeAddress(relative uint16) int16 {
 	return address
 }
 
+// ստեկից վերցված հասցեի ստուգումը. size բայթերը պետք է
+// ամբողջությամբ տեղավորվեն հիշողության մեջ։ Կույտի օբյեկտների
+// ցուցիչներից հեռացվում է PointerTag նշանը
+func (m *Machine) address(value int32, size int32) int16 {
+	value = m.untag(value)
+	m.check(value, size)
+	return int16(value)
+}
+
+// հեռացնել կույտի օբյեկտի ցուցիչի PointerTag նշանը
+func (m *Machine) untag(value int32) int32 {
+	if m.heap != 0 && value&^0xFFFF == PointerTag {
+		value &^= PointerTag
+	}
+	return value
+}
+
+func (m *Machine) check(addr int32, size int32) {
+	if addr < 0 || addr > m.addressSpace()-size {
+		m.trap(TrapMemoryBounds, addr)
+	}
+}
+
+// հիշողության դիմման տեսակը
+type access int
+
+const (
+	accessRead  access = iota // տվյալների ընթերցում
+	accessWrite               // տվյալների գրառում
+	accessFetch               // հրամանի ընթերցում
+)
+
+// addr հասցեից կարդալ len(buffer) բայթ
+func (m *Machine) readBytes(addr int32, buffer []byte, kind access) {
+	m.check(addr, int32(len(buffer)))
+	if m.races != nil && kind == accessRead && !m.atomic {
+		m.races.access(m.thread, addr, len(buffer), false, m.current)
+	}
+	if m.observer != nil && kind == accessRead {
+		m.observer.Read(addr, len(buffer))
+	}
+	for len(buffer) > 0 {
+		physical, count := m.translate(addr, int32(len(buffer)), kind)
+		copy(buffer, m.memory[physical:physical+count])
+		buffer = buffer[count:]
+		addr += count
+	}
+}
+
+// addr հասցեում գրել data-ի բայթերը
+func (m *Machine) writeBytes(addr int32, data []byte) {
+	m.check(addr, int32(len(data)))
+	if m.races != nil && !m.atomic {
+		m.races.access(m.thread, addr, len(data), true, m.current)
+	}
+	if m.observer != nil {
+		m.observer.Write(addr, len(data))
+	}
+	for len(data) > 0 {
+		physical, count := m.translate(addr, int32(len(data)), accessWrite)
+		m.checkWritable(addr, physical, count)
+		copy(m.memory[physical:physical+count], data)
+		data = data[count:]
+		addr += count
+	}
+}
+
+// կարդալ հերթական հրամանի կոդը
+func (m *Machine) fetch() byte {
+	var command [1]byte
+	m.codeBytes(int32(m.ip), command[:])
+	m.ip++
+	return command[0]
+}
+
+func (m *Machine) readByte(addr int16) byte {
+	var value [1]byte
+	m.readBytes(int32(addr), value[:], accessRead)
+	return value[0]
+}
+
+func (m *Machine) writeByte(addr int16, value byte) {
+	m.writeBytes(int32(addr), []byte{value})
+}
+
 func (m *Machine) readWord(addr int16) uint16 {
-	return binary.LittleEndian.Uint16(m.memory[addr:])
+	var value [2]byte
+	m.readBytes(int32(addr), value[:], accessRead)
+	return binary.LittleEndian.Uint16(value[:])
 }
 
 func (m *Machine) read(addr int16) int32 {
-	return int32(binary.LittleEndian.Uint32(m.memory[addr:]))
+	var value [4]byte
+	m.readBytes(int32(addr), value[:], accessRead)
+	return int32(binary.LittleEndian.Uint32(value[:]))
 }
 
 func (m *Machine) write(addr int16, value int32) {
-	binary.LittleEndian.PutUint32(m.memory[addr:], uint32(value))
+	var data [4]byte
+	binary.LittleEndian.PutUint32(data[:], uint32(value))
+	m.writeBytes(int32(addr), data[:])
 }
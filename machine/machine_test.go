@@ -1,6 +1,10 @@
 package machine
 
 import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
 	"svm/bytecode"
 	"testing"
 )
@@ -43,3 +47,524 @@ func TestRun(t *testing.T) {
 	m.Load(program)
 	m.Run()
 }
+
+func TestLoadStore(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, -77)
+	builder.AddWithNumeric(bytecode.Push, 1000)
+	builder.AddBasic(bytecode.Store)
+	builder.AddWithNumeric(bytecode.Push, 1000)
+	builder.AddBasic(bytecode.Load)
+	builder.AddWithNumeric(bytecode.Push, 0x1234)
+	builder.AddWithNumeric(bytecode.Push, 1001)
+	builder.AddBasic(bytecode.StoreB)
+	builder.AddWithNumeric(bytecode.Push, 1001)
+	builder.AddBasic(bytecode.LoadB)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	if v := m.basicPop(); v != 0x34 {
+		t.Errorf("Սպասվում է 0x34, բայց ստացվել է %#x", v)
+	}
+	if v := m.basicPop(); v != -77 {
+		t.Errorf("Սպասվում է -77, բայց ստացվել է %d", v)
+	}
+	if v := m.read(1000); v != -77&^0xff00|0x3400 {
+		t.Errorf("STOREB-ը պետք է փոխի միայն մեկ բայթ, ստացվել է %#x", v)
+	}
+}
+
+func TestMemoryBounds(t *testing.T) {
+	addresses := []int32{-1, MemorySize - 3, MemorySize, 0x7fffffff}
+	for _, address := range addresses {
+		builder := bytecode.NewBuilder()
+		builder.AddWithNumeric(bytecode.Push, address)
+		builder.AddBasic(bytecode.Load)
+		builder.AddBasic(bytecode.Halt)
+
+		m := NewMachine()
+		m.Load(builder.Bytes())
+		err := m.Run()
+		trap, ok := err.(*Trap)
+		if !ok || trap.Code != TrapMemoryBounds {
+			t.Errorf("%d հասցեի համար սպասվում է TrapMemoryBounds, ստացվել է %v", address, err)
+			continue
+		}
+		if trap.Address != address || trap.IP != 5 {
+			t.Errorf("Սխալ ծուղակի նկարագրություն. %d, %d", trap.Address, trap.IP)
+		}
+	}
+}
+
+func TestCallingConvention(t *testing.T) {
+	// max(3, 8) ֆունկցիան՝ մեկ լոկալ փոփոխականով
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 3)
+	builder.AddWithNumeric(bytecode.Push, 8)
+	builder.AddWithLabel(bytecode.Call, "max")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("max")
+	builder.AddWithNumeric(bytecode.Enter, 1)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -16)
+	builder.AddWithAddress(bytecode.Pop, bytecode.FramePointer, 0)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, 0)
+	builder.AddBasic(bytecode.Gt)
+	builder.AddWithLabel(bytecode.Jz, "end")
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12)
+	builder.AddWithAddress(bytecode.Pop, bytecode.FramePointer, 0)
+	builder.SetLabel("end")
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, 0)
+	builder.AddWithNumeric(bytecode.Ret, 2)
+	builder.Validate()
+
+	program := builder.Bytes()
+	m := NewMachine()
+	m.Load(program)
+	base := m.sp
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	if m.sp != base+4 {
+		t.Errorf("RET 2-ից հետո ստեկում պետք է մնա միայն արդյունքը")
+	}
+	if v := m.basicPop(); v != 8 {
+		t.Errorf("Սպասվում է 8, բայց ստացվել է %d", v)
+	}
+}
+
+func TestLeave(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithLabel(bytecode.Call, "f")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("f")
+	builder.AddWithNumeric(bytecode.Enter, 3)
+	builder.AddBasic(bytecode.Leave)
+	builder.AddWithNumeric(bytecode.Push, 5)
+	builder.AddBasic(bytecode.Ret)
+	builder.Validate()
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	base := m.sp
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if m.sp != base+4 || m.basicPop() != 5 {
+		t.Errorf("LEAVE-ը պետք է հեռացնի լոկալ փոփոխականները")
+	}
+}
+
+func TestTryThrow(t *testing.T) {
+	// f-ը կանչում է g-ն, որը նետում է 42, մշակիչը f-ում է
+	builder := bytecode.NewBuilder()
+	builder.AddWithLabel(bytecode.Call, "f")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("f")
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddWithLabel(bytecode.Try, "handler")
+	builder.AddWithNumeric(bytecode.Push, 7)
+	builder.AddWithLabel(bytecode.Call, "g")
+	builder.AddBasic(bytecode.EndTry)
+	builder.AddWithNumeric(bytecode.Push, -1)
+	builder.AddWithNumeric(bytecode.Ret, 0)
+	builder.SetLabel("handler")
+	builder.AddBasic(bytecode.Add)
+	builder.AddBasic(bytecode.Ret)
+	builder.SetLabel("g")
+	builder.AddWithNumeric(bytecode.Push, 2)
+	builder.AddWithNumeric(bytecode.Push, 42)
+	builder.AddBasic(bytecode.Throw)
+	builder.Validate()
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	base := m.sp
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if m.sp != base+4 || m.hp != noHandler {
+		t.Errorf("Բացառությունից հետո ստեկը չի վերականգնվել")
+	}
+	if v := m.basicPop(); v != 43 {
+		t.Errorf("Սպասվում է 43, բայց ստացվել է %d", v)
+	}
+}
+
+func TestReturnFromTry(t *testing.T) {
+	// f-ը վերադառնում է առանց ENDTRY-ի, ուստի դրա մշակիչը պետք է
+	// հեռացվի, և կանչողում բաժանումը զրոյի վրա մշակիչ չունի
+	builder := bytecode.NewBuilder()
+	builder.AddWithLabel(bytecode.Call, "f")
+	for k := range 5 {
+		builder.AddWithNumeric(bytecode.Push, int32(k+1))
+	}
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddBasic(bytecode.Div)
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("f")
+	builder.AddWithLabel(bytecode.Try, "handler")
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddBasic(bytecode.Ret)
+	builder.SetLabel("handler")
+	builder.AddBasic(bytecode.Halt)
+	builder.Validate()
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	var trap *Trap
+	if err := m.Run(); !errors.As(err, &trap) || trap.Code != TrapDivisionByZero {
+		t.Errorf("Սպասվում է բաժանում զրոյի վրա, բայց ստացվել է %v", err)
+	}
+	if m.hp != noHandler {
+		t.Errorf("Վերադարձից հետո մշակիչը չի հեռացվել")
+	}
+}
+
+func TestCatchDivisionByZero(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithLabel(bytecode.Try, "handler")
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddBasic(bytecode.Div)
+	builder.AddBasic(bytecode.EndTry)
+	builder.SetLabel("handler")
+	builder.AddBasic(bytecode.Halt)
+	builder.Validate()
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if v := m.basicPop(); v != -int32(TrapDivisionByZero) {
+		t.Errorf("Սպասվում է %d, բայց ստացվել է %d", -TrapDivisionByZero, v)
+	}
+}
+
+func TestUnhandledException(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 5)
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddBasic(bytecode.Mod)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	if trap, ok := m.Run().(*Trap); !ok || trap.Code != TrapDivisionByZero {
+		t.Errorf("Սպասվում է TrapDivisionByZero")
+	}
+
+	builder = bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 13)
+	builder.AddBasic(bytecode.Throw)
+
+	m = NewMachine()
+	m.Load(builder.Bytes())
+	trap, ok := m.Run().(*Trap)
+	if !ok || trap.Code != TrapUnhandledException || trap.Value != 13 {
+		t.Errorf("Սպասվում է չմշակված 13 բացառությունը")
+	}
+}
+
+func TestTailCall(t *testing.T) {
+	// sum(n, acc) = n == 0 ? acc : sum(n - 1, acc + n)
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 10000)
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddWithLabel(bytecode.Call, "sum")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("sum")
+	builder.AddWithNumeric(bytecode.Enter, 2)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -16)
+	builder.AddWithLabel(bytecode.Jz, "done")
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -16)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.Sub)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -16)
+	builder.AddBasic(bytecode.Add)
+	builder.AddTailCall("sum", 2, 2)
+	builder.SetLabel("done")
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12)
+	builder.AddWithNumeric(bytecode.Ret, 2)
+	builder.Validate()
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	base := m.sp
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if m.sp != base+4 {
+		t.Errorf("TAILCALL-ից հետո ստեկը չի վերականգնվել")
+	}
+	if v := m.basicPop(); v != 50005000 {
+		t.Errorf("Սպասվում է 50005000, բայց ստացվել է %d", v)
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		left, right int32
+		opcode      byte
+		strict      bool
+		overflow    bool
+	}{
+		{math.MaxInt32, 1, bytecode.Add, false, false},
+		{math.MaxInt32, 1, bytecode.AddO, false, true},
+		{math.MaxInt32, 1, bytecode.Add, true, true},
+		{math.MinInt32, 1, bytecode.SubO, false, true},
+		{math.MinInt32, 1, bytecode.Sub, true, true},
+		{-5, 7, bytecode.SubO, false, false},
+		{65536, 65536, bytecode.MulO, false, true},
+		{65536, 65536, bytecode.Mul, false, false},
+		{-46341, 46340, bytecode.MulO, false, false},
+		{math.MinInt32, -1, bytecode.Div, false, false},
+		{math.MinInt32, -1, bytecode.Div, true, true},
+		{math.MinInt32, -1, bytecode.Mod, true, true},
+		{math.MinInt32, 2, bytecode.Div, true, false},
+	}
+
+	for _, test := range tests {
+		builder := bytecode.NewBuilder()
+		builder.AddWithNumeric(bytecode.Push, test.left)
+		builder.AddWithNumeric(bytecode.Push, test.right)
+		builder.AddBasic(test.opcode)
+		builder.AddBasic(bytecode.Halt)
+
+		options := []Option{}
+		if test.strict {
+			options = append(options, WithStrictArithmetic())
+		}
+		m := NewMachine(options...)
+		m.Load(builder.Bytes())
+		err := m.Run()
+		trap, ok := err.(*Trap)
+		overflow := ok && trap.Code == TrapOverflow
+		if overflow != test.overflow {
+			t.Errorf("%s %d %d: սպասվում է գերլցում՝ %v, ստացվել է %v",
+				bytecode.Mnemonics[test.opcode], test.left, test.right, test.overflow, err)
+		}
+	}
+}
+
+func TestCheckedNegation(t *testing.T) {
+	for _, opcode := range []byte{bytecode.Neg, bytecode.NegO} {
+		builder := bytecode.NewBuilder()
+		builder.AddWithNumeric(bytecode.Push, math.MinInt32)
+		builder.AddBasic(opcode)
+		builder.AddBasic(bytecode.Halt)
+
+		m := NewMachine()
+		m.Load(builder.Bytes())
+		err := m.Run()
+		if opcode == bytecode.Neg && err != nil {
+			t.Errorf("NEG-ը չպետք է ստուգի գերլցումը")
+		}
+		if trap, ok := err.(*Trap); opcode == bytecode.NegO && (!ok || trap.Code != TrapOverflow) {
+			t.Errorf("NEGO-ի համար սպասվում է TrapOverflow")
+		}
+	}
+}
+
+func TestJumpTable(t *testing.T) {
+	for index, expected := range map[int32]int32{-1: 99, 0: 10, 1: 11, 2: 12, 3: 99} {
+		builder := bytecode.NewBuilder()
+		builder.AddWithNumeric(bytecode.Push, index)
+		builder.AddWithLabel(bytecode.JumpTab, "cases")
+		builder.SetLabel("zero")
+		builder.AddWithNumeric(bytecode.Push, 10)
+		builder.AddBasic(bytecode.Halt)
+		builder.SetLabel("one")
+		builder.AddWithNumeric(bytecode.Push, 11)
+		builder.AddBasic(bytecode.Halt)
+		builder.SetLabel("two")
+		builder.AddWithNumeric(bytecode.Push, 12)
+		builder.AddBasic(bytecode.Halt)
+		builder.SetLabel("other")
+		builder.AddWithNumeric(bytecode.Push, 99)
+		builder.AddBasic(bytecode.Halt)
+		builder.SetLabel("cases")
+		builder.AddJumpTable("other", "zero", "one", "two")
+		builder.Validate()
+
+		m := NewMachine()
+		m.Load(builder.Bytes())
+		if err := m.Run(); err != nil {
+			t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+		}
+		if v := m.basicPop(); v != expected {
+			t.Errorf("%d ինդեքսի համար սպասվում է %d, բայց ստացվել է %d", index, expected, v)
+		}
+	}
+}
+
+func TestBlockMemory(t *testing.T) {
+	m := NewMachine()
+	copy(m.memory[2000:], "abcdefgh")
+
+	builder := bytecode.NewBuilder()
+	// համընկնող տիրույթներ՝ "abcdefgh" -> "ababcdef"
+	builder.AddWithNumeric(bytecode.Push, 2002)
+	builder.AddWithNumeric(bytecode.Push, 2000)
+	builder.AddWithNumeric(bytecode.Push, 6)
+	builder.AddBasic(bytecode.MemCpy)
+	// "ababcdef" -> "ababcdef" + "zzz" 2100 հասցեում
+	builder.AddWithNumeric(bytecode.Push, 2100)
+	builder.AddWithNumeric(bytecode.Push, 'z')
+	builder.AddWithNumeric(bytecode.Push, 3)
+	builder.AddBasic(bytecode.MemSet)
+	// "aba" < "zzz"
+	builder.AddWithNumeric(bytecode.Push, 2000)
+	builder.AddWithNumeric(bytecode.Push, 2100)
+	builder.AddWithNumeric(bytecode.Push, 3)
+	builder.AddBasic(bytecode.MemCmp)
+	// "ab" == "ab"
+	builder.AddWithNumeric(bytecode.Push, 2000)
+	builder.AddWithNumeric(bytecode.Push, 2002)
+	builder.AddWithNumeric(bytecode.Push, 2)
+	builder.AddBasic(bytecode.MemCmp)
+	builder.AddBasic(bytecode.Halt)
+
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	if s := string(m.memory[2000:2008]); s != "ababcdef" {
+		t.Errorf("Սպասվում է \"ababcdef\", ստացվել է %q", s)
+	}
+	if s := string(m.memory[2100:2104]); s != "zzz\x00" {
+		t.Errorf("Սպասվում է \"zzz\", ստացվել է %q", s)
+	}
+	if v := m.basicPop(); v != 0 {
+		t.Errorf("Սպասվում է 0, բայց ստացվել է %d", v)
+	}
+	if v := m.basicPop(); v != -1 {
+		t.Errorf("Սպասվում է -1, բայց ստացվել է %d", v)
+	}
+}
+
+func TestBlockMemoryBounds(t *testing.T) {
+	for _, length := range []int32{-1, MemorySize} {
+		builder := bytecode.NewBuilder()
+		builder.AddWithNumeric(bytecode.Push, 100)
+		builder.AddWithNumeric(bytecode.Push, 0)
+		builder.AddWithNumeric(bytecode.Push, length)
+		builder.AddBasic(bytecode.MemSet)
+		builder.AddBasic(bytecode.Halt)
+
+		m := NewMachine()
+		m.Load(builder.Bytes())
+		if trap, ok := m.Run().(*Trap); !ok || trap.Code != TrapMemoryBounds {
+			t.Errorf("%d երկարության համար սպասվում է TrapMemoryBounds", length)
+		}
+	}
+}
+
+func TestProgramArguments(t *testing.T) {
+	// main(argc, argv). argc-ն արտածել PRINT-ով, երկրորդ արգումենտի
+	// առաջին բայթը՝ EPRINT-ով, և ավարտել argc+40 կոդով
+	builder := bytecode.NewBuilder()
+	builder.AddWithLabel(bytecode.Call, "main")
+	builder.AddWithNumeric(bytecode.Halt, 3)
+	builder.SetLabel("main")
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -16)
+	builder.AddBasic(bytecode.Print)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -12)
+	builder.AddWithNumeric(bytecode.Push, 4)
+	builder.AddBasic(bytecode.Add)
+	builder.AddBasic(bytecode.Load)
+	builder.AddBasic(bytecode.LoadB)
+	builder.AddBasic(bytecode.EPrint)
+	builder.AddBasic(bytecode.Input)
+	builder.AddBasic(bytecode.Print)
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddWithNumeric(bytecode.Ret, 2)
+	builder.Validate()
+
+	stdout := bytes.NewBufferString("")
+	stderr := bytes.NewBufferString("")
+	m := NewMachine(
+		WithStdin(strings.NewReader("  -15\n")),
+		WithStdout(stdout),
+		WithStderr(stderr))
+	m.Load(builder.Bytes())
+	if err := m.SetArguments([]string{"first", "xyz"}); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	if stdout.String() != "2\n-15\n" {
+		t.Errorf("Սպասվում է \"2\\n-15\\n\", ստացվել է %q", stdout.String())
+	}
+	if stderr.String() != "120\n" {
+		t.Errorf("Սպասվում է \"120\\n\", ստացվել է %q", stderr.String())
+	}
+	if m.ExitCode() != 3 {
+		t.Errorf("Սպասվում է 3 ավարտի կոդը, ստացվել է %d", m.ExitCode())
+	}
+}
+
+func TestTooManyArguments(t *testing.T) {
+	m := NewMachine()
+	m.Load([]byte{bytecode.Halt})
+	if err := m.SetArguments([]string{strings.Repeat("a", MemorySize)}); err == nil {
+		t.Errorf("Սպասվում է TrapStackOverflow")
+	}
+}
+
+func TestAssert(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.Assert)
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddBasic(bytecode.Assert)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	if trap, ok := m.Run().(*Trap); !ok || trap.Code != TrapAssertion || trap.IP != 11 {
+		t.Errorf("Սպասվում է TrapAssertion 11 հասցեում")
+	}
+
+	// ձախողված պնդումը չի փոխանցվում մշակիչներին
+	builder = bytecode.NewBuilder()
+	builder.AddWithLabel(bytecode.Try, "handler")
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddBasic(bytecode.Assert)
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("handler")
+	builder.AddBasic(bytecode.Halt)
+	builder.Validate()
+
+	m = NewMachine()
+	m.Load(builder.Bytes())
+	m.control[ControlVector] = int32(builder.Symbols()["handler"])
+	if trap, ok := m.Run().(*Trap); !ok || trap.Code != TrapAssertion {
+		t.Errorf("Սպասվում է TrapAssertion, այլ ոչ թե մշակիչի կատարում")
+	}
+}
+
+func TestStepLimit(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.SetLabel("loop")
+	builder.AddWithLabel(bytecode.Try, "loop")
+	builder.AddWithLabel(bytecode.Jump, "loop")
+	builder.Validate()
+
+	m := NewMachine(WithStepLimit(100))
+	m.Load(builder.Bytes())
+	if trap, ok := m.Run().(*Trap); !ok || trap.Code != TrapStepLimit {
+		t.Errorf("Սպասվում է TrapStepLimit")
+	}
+}
@@ -0,0 +1,223 @@
+package machine
+
+import "encoding/binary"
+
+// Հիշողության կառավարման բլոկը (MMU) միացվում է ծրագրի կողմից՝ STATUS
+// ռեգիստրի PAGING բիթով։ Միացված վիճակում բոլոր հասցեները (հրամանների,
+// ստեկի, LOAD/STORE-ի) վիրտուալ են. դրանք բաժանվում են PageSize բայթանոց
+// էջերի, և ամեն էջի ֆիզիկական շրջանակը որոշվում է էջերի աղյուսակից։
+// Աղյուսակը գտնվում է ֆիզիկական հիշողության մեջ՝ PTB հասցեից, և
+// պարունակում է մեկ 4 բայթանոց գրառում ամեն վիրտուալ էջի համար.
+//
+//	բիթ 0      — էջը ներկա է (PagePresent)
+//	բիթ 1      — էջում կարելի է գրել (PageWritable)
+//	բիթ 2      — էջը հասանելի է օգտագործողի ռեժիմում (PageUser)
+//	բիթեր 8-15 — ֆիզիկական շրջանակի համարը
+//
+// Բացակա էջին կամ միայն կարդալու էջում գրելու դիմումն առաջացնում է
+// TrapPageFault ծուղակ։ Եթե TVEC ռեգիստրը զրո չէ, ապա ծուղակը
+// փոխանցվում է այդ հասցեում գտնվող մշակիչին, որը կարող է ուղղել էջերի
+// աղյուսակն ու IRET-ով նորից կատարել ծուղակն առաջացրած հրամանը։
+
+// կառավարման ռեգիստրների համարները GETCR n և SETCR n հրամանների համար
+const (
+	ControlStatus = 0 // մեքենայի վիճակը
+	ControlPTB    = 1 // էջերի աղյուսակի ֆիզիկական հասցեն
+	ControlVector = 2 // ծուղակների մշակիչի հասցեն, 0՝ եթե մշակիչ չկա
+	ControlFault  = 3 // վերջին էջային խափանման վիրտուալ հասցեն
+
+	ControlKernelSP    = 4 // միջուկի ստեկի հասցեն
+	ControlKernelEntry = 5 // SYSENTER-ի մուտքի կետը
+
+	controlRegisters = 6
+)
+
+// STATUS ռեգիստրի բիթերը
+const (
+	StatusPaging int32 = 1 << 0 // վիրտուալ հիշողությունը միացված է
+	StatusUser   int32 = 1 << 1 // մեքենան օգտագործողի ռեժիմում է
+)
+
+// էջերի աղյուսակի գրառման բիթերը
+const (
+	PagePresent  int32 = 1 << 0 // էջը ներկա է
+	PageWritable int32 = 1 << 1 // էջում կարելի է գրել
+	PageUser     int32 = 1 << 2 // էջը հասանելի է օգտագործողի ռեժիմում
+)
+
+const (
+	PageSize    = 256     // էջի չափը բայթերով
+	VirtualSize = 1 << 15 // վիրտուալ հասցեների տիրույթի չափը
+)
+
+// TLB-ի վիճակագրությունը
+type TLBStats struct {
+	Hits   int // թարգմանություններ, որոնք գտնվել են TLB-ում
+	Misses int // թարգմանություններ, որոնց համար կարդացվել է էջերի աղյուսակը
+}
+
+// TLB-ի վիճակագրությունը
+func (m *Machine) TLBStats() TLBStats {
+	return m.tlbStats
+}
+
+// հասանելի հասցեների տիրույթի չափը
+func (m *Machine) addressSpace() int32 {
+	if m.paging() {
+		return VirtualSize
+	}
+	return int32(len(m.memory))
+}
+
+func (m *Machine) paging() bool {
+	return m.control[ControlStatus]&StatusPaging != 0
+}
+
+// addr վիրտուալ հասցեն թարգմանել ֆիզիկականի. վերադարձնում է ֆիզիկական
+// հասցեն և size բայթերից այն քանակը, որը գտնվում է նույն էջում
+func (m *Machine) translate(addr int32, size int32, kind access) (int32, int32) {
+	if !m.paging() {
+		return addr, size
+	}
+
+	page := addr / PageSize
+	offset := addr % PageSize
+	size = min(size, PageSize-offset)
+
+	entry, found := m.tlb[page]
+	switch {
+	case m.inspecting:
+		entry = m.pageEntry(page)
+	case found:
+		m.tlbStats.Hits++
+	default:
+		m.tlbStats.Misses++
+		entry = m.pageEntry(page)
+		if entry&PagePresent != 0 {
+			m.tlb[page] = entry
+		}
+	}
+
+	// մշակիչը կարող է փոխել էջի գրառումը՝ առանց TLB-ն մաքրելու, ուստի
+	// խափանումից առաջ TLB-ի գրառումը ստուգվում է աղյուսակով
+	if found && !m.inspecting && !m.permitted(entry, kind) {
+		entry = m.pageEntry(page)
+		if entry&PagePresent != 0 {
+			m.tlb[page] = entry
+		} else {
+			delete(m.tlb, page)
+		}
+	}
+
+	if !m.permitted(entry, kind) {
+		if !m.inspecting {
+			m.control[ControlFault] = addr
+		}
+		m.trap(TrapPageFault, addr)
+	}
+
+	physical := (entry>>8&0xFF)*PageSize + offset
+	if physical+size > int32(len(m.memory)) {
+		m.trap(TrapMemoryBounds, addr)
+	}
+	return physical, size
+}
+
+// արդյոք էջի entry գրառումը թույլատրում է kind դիմումը ընթացիկ ռեժիմում
+func (m *Machine) permitted(entry int32, kind access) bool {
+	return entry&PagePresent != 0 && (kind != accessWrite || entry&PageWritable != 0) &&
+		(entry&PageUser != 0 || m.Mode() != ModeUser)
+}
+
+// ստուգել, որ addr հասցեից սկսվող size բայթերը թարգմանվում են kind
+// դիմման համար՝ առանց դրանք կարդալու կամ գրելու. այսպես հրամանը
+// խափանվում է մինչև առաջին գրելը և IRET-ից հետո կատարվում է նույն
+// արդյունքով
+func (m *Machine) probe(addr int32, size int32, kind access) {
+	for size > 0 {
+		physical, count := m.translate(addr, size, kind)
+		if kind == accessWrite {
+			m.checkWritable(addr, physical, count)
+		}
+		addr += count
+		size -= count
+	}
+}
+
+// թարգմանել հասցեները առանց TLB-ի, դրա վիճակագրության և FAULT-ի
+// փոփոխման, որպեսզի կարգաբերիչի դիմումները չազդեն կատարման վրա.
+// վերադարձնում է նախորդ ռեժիմը վերականգնող ֆունկցիան
+func (m *Machine) inspect() func() {
+	inspecting := m.inspecting
+	m.inspecting = true
+	return func() { m.inspecting = inspecting }
+}
+
+// կարդալ page էջի գրառումը էջերի աղյուսակից. աղյուսակից դուրս
+// գտնվող գրառումը համարվում է բացակա էջ
+func (m *Machine) pageEntry(page int32) int32 {
+	address := m.control[ControlPTB] + 4*page
+	if address < 0 || address > int32(len(m.memory))-4 {
+		return 0
+	}
+	return int32(binary.LittleEndian.Uint32(m.memory[address:]))
+}
+
+// մաքրել TLB-ն
+func (m *Machine) flush() {
+	clear(m.tlb)
+}
+
+// կառավարման ռեգիստրի համարը
+func (m *Machine) controlRegister() int32 {
+	number := m.readCode(m.ip)
+	m.ip += 4
+	if number < 0 || number >= controlRegisters {
+		m.trap(TrapMemoryBounds, number)
+	}
+	return number
+}
+
+// GETCR n. ստեկում գրել n կառավարման ռեգիստրի արժեքը
+func (m *Machine) getControl() {
+	number := m.controlRegister()
+	m.basicPush(m.control[number])
+}
+
+// SETCR n. ստեկից վերցնել n կառավարման ռեգիստրի նոր արժեքը.
+// STATUS-ի կամ PTB-ի փոփոխությունը մաքրում է TLB-ն
+func (m *Machine) setControl() {
+	number := m.controlRegister()
+	value := m.basicPop()
+	m.control[number] = value
+	if number == ControlStatus || number == ControlPTB {
+		m.flush()
+	}
+}
+
+// ծուղակը փոխանցել TVEC մշակիչին՝ վերականգնելով ծուղակն առաջացրած
+// հրամանի սկզբի վիճակը, որպեսզի IRET-ից հետո այն կատարվի նորից.
+// վերականգնվում են միայն SP-ն, FP-ն և HP-ն, ուստի հիշողություն գրող
+// հրամանները (MEMCPY, SYS-ի ընթերցումը) նախապես ստուգում են ամբողջ
+// տիրույթը probe-ով, իսկ SYS-ի մյուս գործողությունները կրկնվում են,
+// եթե խափանվում է արդյունքը ստեկում գրելը
+func (m *Machine) interrupt(trap *Trap) {
+	m.sp, m.fp, m.hp = m.saved.sp, m.saved.fp, m.saved.hp
+	m.enterKernel(m.current, int32(trap.Code), m.control[ControlVector])
+}
+
+// IRET. վերականգնել ծուղակից առաջ պահված վիճակը և շարունակել
+// կատարումը պահված IP-ից
+func (m *Machine) interruptReturn() {
+	m.sp = m.fp
+	m.basicPop() // ծուղակի կոդը
+	status := m.basicPop()
+	fp := m.basicPop()
+	ip := m.basicPop()
+	sp := m.basicPop()
+	if status != m.control[ControlStatus] {
+		m.control[ControlStatus] = status
+		m.flush()
+	}
+	m.fp, m.ip, m.sp = int16(fp), int16(ip), int16(sp)
+}
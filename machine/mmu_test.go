@@ -0,0 +1,227 @@
+package machine
+
+import (
+	"encoding/binary"
+	"errors"
+	"svm/bytecode"
+	"testing"
+)
+
+// էջերի աղյուսակը 0x3000 հասցեում. 0-63 էջերը արտապատկերված են
+// նույն համարով շրջանակներին, 64-րդ էջը միայն կարդալու համար է
+func identityPages(m *Machine) {
+	for page := range int16(64) {
+		m.write(0x3000+4*page, int32(page)<<8|PagePresent|PageWritable)
+	}
+	m.write(0x3000+4*64, 10<<8|PagePresent)
+}
+
+// միացնել վիրտուալ հիշողությունը
+func enablePaging(builder *bytecode.Builder) {
+	builder.AddWithNumeric(bytecode.Push, 0x3000)
+	builder.AddWithNumeric(bytecode.SetCR, ControlPTB)
+	builder.AddWithNumeric(bytecode.Push, StatusPaging)
+	builder.AddWithNumeric(bytecode.SetCR, ControlStatus)
+}
+
+func TestPageFaultHandler(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	enablePaging(builder)
+	// 100-րդ էջը դեռ արտապատկերված չէ
+	builder.AddWithNumeric(bytecode.Push, 777)
+	builder.AddWithNumeric(bytecode.Push, 0x6404)
+	builder.AddBasic(bytecode.Store)
+	builder.AddWithNumeric(bytecode.Push, 0x6404)
+	builder.AddBasic(bytecode.Load)
+	builder.AddBasic(bytecode.Halt)
+	// մշակիչը 100-րդ էջն արտապատկերում է 40-րդ շրջանակին
+	builder.SetLabel("handler")
+	builder.AddWithNumeric(bytecode.GetCR, ControlFault)
+	builder.AddWithNumeric(bytecode.Push, 0x2000)
+	builder.AddBasic(bytecode.Store)
+	builder.AddWithNumeric(bytecode.Push, 40<<8|PagePresent|PageWritable)
+	builder.AddWithNumeric(bytecode.Push, 0x3000+4*100)
+	builder.AddBasic(bytecode.Store)
+	builder.AddWithNumeric(bytecode.Push, 0x3000)
+	builder.AddWithNumeric(bytecode.SetCR, ControlPTB)
+	builder.AddBasic(bytecode.IRet)
+	builder.Validate()
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	identityPages(m)
+	m.control[ControlVector] = int32(builder.Symbols()["handler"])
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	if v := m.basicPop(); v != 777 {
+		t.Errorf("Սպասվում է 777, բայց ստացվել է %d", v)
+	}
+	if v := m.read(0x2000); v != 0x6404 {
+		t.Errorf("Սպասվում է խափանման %d հասցեն, բայց ստացվել է %d", 0x6404, v)
+	}
+	if v := binary.LittleEndian.Uint32(m.memory[40*PageSize+4:]); v != 777 {
+		t.Errorf("Ֆիզիկական հիշողությունում սպասվում է 777, բայց ստացվել է %d", v)
+	}
+	if stats := m.TLBStats(); stats.Hits == 0 || stats.Misses == 0 {
+		t.Errorf("Սխալ վիճակագրություն. %+v", stats)
+	}
+}
+
+func TestPageFault(t *testing.T) {
+	tests := []struct {
+		name    string
+		address int32
+		write   bool
+	}{
+		{"բացակա էջ", 0x6400, false},
+		{"միայն կարդալու էջ", 0x4010, true},
+	}
+
+	for _, test := range tests {
+		builder := bytecode.NewBuilder()
+		enablePaging(builder)
+		if test.write {
+			builder.AddWithNumeric(bytecode.Push, 1)
+			builder.AddWithNumeric(bytecode.Push, test.address)
+			builder.AddBasic(bytecode.Store)
+		} else {
+			builder.AddWithNumeric(bytecode.Push, test.address)
+			builder.AddBasic(bytecode.Load)
+		}
+		builder.AddBasic(bytecode.Halt)
+
+		m := NewMachine()
+		m.Load(builder.Bytes())
+		identityPages(m)
+		var trap *Trap
+		err := m.Run()
+		if !errors.As(err, &trap) || trap.Code != TrapPageFault || trap.Address != test.address {
+			t.Errorf("%s. սպասվում է էջային խափանում, բայց ստացվել է %v", test.name, err)
+		}
+	}
+}
+
+func TestRestartMemoryCopy(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	enablePaging(builder)
+	// համընկնող տիրույթների պատճենումը հետ. նպատակի վերջը 65-րդ էջում է,
+	// իսկ սկիզբը՝ միայն կարդալու 64-րդ էջում
+	builder.AddWithNumeric(bytecode.Push, 0x40FC)
+	builder.AddWithNumeric(bytecode.Push, 0x40F4)
+	builder.AddWithNumeric(bytecode.Push, 16)
+	builder.AddBasic(bytecode.MemCpy)
+	builder.AddBasic(bytecode.Halt)
+	// մշակիչը 64-րդ էջը դարձնում է գրելու համար
+	builder.SetLabel("handler")
+	builder.AddWithNumeric(bytecode.Push, 10<<8|PagePresent|PageWritable)
+	builder.AddWithNumeric(bytecode.Push, 0x3000+4*64)
+	builder.AddBasic(bytecode.Store)
+	builder.AddWithNumeric(bytecode.Push, 0x3000)
+	builder.AddWithNumeric(bytecode.SetCR, ControlPTB)
+	builder.AddBasic(bytecode.IRet)
+	builder.Validate()
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	identityPages(m)
+	m.write(0x3000+4*65, 41<<8|PagePresent|PageWritable)
+	m.control[ControlVector] = int32(builder.Symbols()["handler"])
+	// աղբյուրը՝ 0x40F4-0x4103 վիրտուալ հասցեները
+	var source [16]byte
+	for i := range source {
+		source[i] = byte(i + 1)
+	}
+	copy(m.memory[10*PageSize+0xF4:], source[:12])
+	copy(m.memory[41*PageSize:], source[12:])
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	var target [16]byte
+	copy(target[:4], m.memory[10*PageSize+0xFC:])
+	copy(target[4:], m.memory[41*PageSize:])
+	if target != source {
+		t.Errorf("Սպասվում է %v, բայց ստացվել է %v", source, target)
+	}
+}
+
+func TestInvalidControlRegister(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.GetCR, 100)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	var trap *Trap
+	err := m.Run()
+	if !errors.As(err, &trap) || trap.Code != TrapMemoryBounds || trap.Address != 100 {
+		t.Errorf("Սպասվում է TrapMemoryBounds, բայց ստացվել է %v", err)
+	}
+}
+
+func TestInspectionWithoutSideEffects(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	enablePaging(builder)
+	builder.AddWithNumeric(bytecode.Push, 0x2000)
+	builder.AddBasic(bytecode.Load)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	identityPages(m)
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	stats, entries := m.TLBStats(), len(m.tlb)
+	if _, err := m.ReadMemory(0x1000, 4); err != nil {
+		t.Errorf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if err := m.WriteMemory(0x1000, []byte{1}); err != nil {
+		t.Errorf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if _, err := m.ReadMemory(0x6400, 4); err == nil {
+		t.Errorf("Բացակա էջի համար սպասվում է սխալ")
+	}
+	if m.TLBStats() != stats || len(m.tlb) != entries {
+		t.Errorf("TLB-ն չպետք է փոխվի. %+v, %d գրառում", m.TLBStats(), len(m.tlb))
+	}
+	if fault := m.control[ControlFault]; fault != 0 {
+		t.Errorf("FAULT-ը չպետք է փոխվի, բայց ստացվել է %d", fault)
+	}
+}
+
+func TestStaleWritePermission(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	enablePaging(builder)
+	// 64-րդ էջը կարդալը այն բերում է TLB, իսկ գրելը խափանվում է
+	builder.AddWithNumeric(bytecode.Push, 0x4010)
+	builder.AddBasic(bytecode.Load)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.Add)
+	builder.AddWithNumeric(bytecode.Push, 0x4010)
+	builder.AddBasic(bytecode.Store)
+	builder.AddBasic(bytecode.Halt)
+	// մշակիչը էջը դարձնում է գրելու համար՝ առանց TLB-ն մաքրելու
+	builder.SetLabel("handler")
+	builder.AddWithNumeric(bytecode.Push, 10<<8|PagePresent|PageWritable)
+	builder.AddWithNumeric(bytecode.Push, 0x3000+4*64)
+	builder.AddBasic(bytecode.Store)
+	builder.AddBasic(bytecode.IRet)
+	builder.Validate()
+
+	// հնացած գրառման դեպքում STORE-ը կխափանվեր անվերջ
+	m := NewMachine(WithStepLimit(1000))
+	m.Load(builder.Bytes())
+	identityPages(m)
+	m.control[ControlVector] = int32(builder.Symbols()["handler"])
+	binary.LittleEndian.PutUint32(m.memory[10*PageSize+0x10:], 55)
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if v := binary.LittleEndian.Uint32(m.memory[10*PageSize+0x10:]); v != 56 {
+		t.Errorf("Սպասվում է 56, բայց ստացվել է %d", v)
+	}
+}
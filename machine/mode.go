@@ -0,0 +1,76 @@
+package machine
+
+import "svm/bytecode"
+
+// Մեքենան աշխատում է երկու ռեժիմով. միջուկի (supervisor) և
+// օգտագործողի։ Ռեժիմը որոշվում է STATUS ռեգիստրի StatusUser բիթով։
+// Օգտագործողի ռեժիմում արգելված են կառավարման ռեգիստրների հետ
+// աշխատող հրամանները, IRET-ը, HALT-ը և ներածման-արտածման հրամանները,
+// իսկ վիրտուալ հիշողությունը միացված լինելիս հասանելի են միայն
+// PageUser բիթով նշված էջերը։ Օգտագործողի ծրագիրը միջուկին դիմում է
+// SYSENTER հրամանով, որը կատարումը փոխանցում է KENTRY հասցեին՝ KSP
+// միջուկի ստեկի վրա։ Ծուղակներն օգտագործողի ռեժիմից նույնպես
+// մշակվում են միջուկի ռեժիմում՝ միջուկի ստեկի վրա։
+
+// մեքենայի կատարման ռեժիմը
+type Mode int
+
+const (
+	ModeKernel Mode = iota // միջուկի ռեժիմ
+	ModeUser               // օգտագործողի ռեժիմ
+)
+
+func (mode Mode) String() string {
+	if mode == ModeUser {
+		return "օգտագործողի ռեժիմ"
+	}
+	return "միջուկի ռեժիմ"
+}
+
+// կատարման ընթացիկ ռեժիմը
+func (m *Machine) Mode() Mode {
+	if m.control[ControlStatus]&StatusUser != 0 {
+		return ModeUser
+	}
+	return ModeKernel
+}
+
+// արդյոք opcode հրամանը թույլատրված է միայն միջուկի ռեժիմում
+func privileged(opcode byte) bool {
+	switch opcode {
+	case bytecode.GetCR, bytecode.SetCR, bytecode.IRet, bytecode.Halt,
+		bytecode.Input, bytecode.Print, bytecode.EPrint, bytecode.Sys:
+		return true
+	}
+	return false
+}
+
+// SYSENTER. կանչել միջուկը KENTRY հասցեով
+func (m *Machine) systemEnter() {
+	entry := m.control[ControlKernelEntry]
+	if entry == 0 {
+		m.trap(TrapInvalidSystemCall, 0)
+	}
+	m.enterKernel(m.ip, 0, entry)
+}
+
+// անցնել միջուկի ռեժիմին ու կատարումը շարունակել entry հասցեից.
+// օգտագործողի ռեժիմից անցնելիս ստեկը փոխարինվում է միջուկի ստեկով։
+// Ստեկում գրվում են SP-ն, ip-ն, FP-ն, STATUS-ը և code-ը, իսկ FP-ն
+// ցույց է տալիս դրանց վերջը. [FP-4]՝ code, [FP-8]՝ STATUS, [FP-12]՝ FP,
+// [FP-16]՝ IP, [FP-20]՝ SP
+func (m *Machine) enterKernel(ip int16, code int32, entry int32) {
+	sp := m.sp
+	status := m.control[ControlStatus]
+	if status&StatusUser != 0 {
+		m.control[ControlStatus] &^= StatusUser
+		m.sp = int16(m.control[ControlKernelSP])
+	}
+	m.basicPush(int32(sp))
+	m.basicPush(int32(ip))
+	m.basicPush(int32(m.fp))
+	m.basicPush(status)
+	m.basicPush(code)
+	m.fp = m.sp
+	m.ip = int16(entry)
+}
@@ -0,0 +1,78 @@
+package machine
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"svm/bytecode"
+	"testing"
+)
+
+func TestUserMode(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 0x3800)
+	builder.AddWithNumeric(bytecode.SetCR, ControlKernelSP)
+	builder.AddLabelAddress(bytecode.Push, "kernel")
+	builder.AddWithNumeric(bytecode.SetCR, ControlKernelEntry)
+	builder.AddLabelAddress(bytecode.Push, "fault")
+	builder.AddWithNumeric(bytecode.SetCR, ControlVector)
+	builder.AddWithNumeric(bytecode.Push, StatusUser)
+	builder.AddWithNumeric(bytecode.SetCR, ControlStatus)
+	// օգտագործողի ծրագիրը
+	builder.AddWithNumeric(bytecode.Push, 5)
+	builder.AddBasic(bytecode.SysEnter)
+	builder.AddWithNumeric(bytecode.GetCR, ControlStatus)
+	builder.AddBasic(bytecode.Halt)
+	// համակարգային կանչը արտածում է օգտագործողի ստեկի գագաթը
+	builder.SetLabel("kernel")
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -20)
+	builder.AddWithNumeric(bytecode.Push, 4)
+	builder.AddBasic(bytecode.Sub)
+	builder.AddBasic(bytecode.Load)
+	builder.AddBasic(bytecode.Print)
+	builder.AddBasic(bytecode.IRet)
+	// ծուղակների մշակիչը արտածում է ծուղակի կոդը և պահված STATUS-ը
+	builder.SetLabel("fault")
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -4)
+	builder.AddBasic(bytecode.Print)
+	builder.AddWithAddress(bytecode.Push, bytecode.FramePointer, -8)
+	builder.AddBasic(bytecode.Print)
+	builder.AddWithNumeric(bytecode.Halt, 3)
+	builder.Validate()
+
+	stdout := bytes.NewBufferString("")
+	m := NewMachine(WithStdout(stdout))
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	expected := "5\n10\n2\n"
+	if stdout.String() != expected {
+		t.Errorf("Սպասվում է %q, բայց ստացվել է %q", expected, stdout.String())
+	}
+	if m.ExitCode() != 3 || m.Mode() != ModeKernel {
+		t.Errorf("Սպասվում է 3 կոդ միջուկի ռեժիմում, բայց ստացվել է %d, %s", m.ExitCode(), m.Mode())
+	}
+	if m.sp < 0x3800 {
+		t.Errorf("Մշակիչը պետք է աշխատի միջուկի ստեկի վրա, SP = %04x", m.sp)
+	}
+}
+
+func TestPrivilegedInstruction(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, StatusUser)
+	builder.AddWithNumeric(bytecode.SetCR, ControlStatus)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	var trap *Trap
+	err := m.Run()
+	if !errors.As(err, &trap) || trap.Code != TrapPrivileged || trap.Mode != ModeUser {
+		t.Fatalf("Սպասվում է արգելված հրամանի ծուղակ, բայց ստացվել է %v", err)
+	}
+	if !strings.Contains(err.Error(), ModeUser.String()) {
+		t.Errorf("Սխալի հաղորդագրության մեջ սպասվում է ռեժիմը. %s", err)
+	}
+}
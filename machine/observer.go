@@ -0,0 +1,193 @@
+package machine
+
+import (
+	"context"
+	"log/slog"
+	"svm/bytecode"
+)
+
+// Observer-ը ստանում է մեքենայի կատարման իրադարձությունները։ Այն
+// տեղադրվում է WithObserver կարգավորմամբ, իսկ առանց դիտորդի մեքենան
+// իրադարձություններ չի ստեղծում։ Դիտորդի մեթոդները կանչվում են
+// կատարման ընթացքում և չպետք է փոխեն մեքենայի վիճակը։
+type Observer interface {
+	// հրամանի կատարումից առաջ. registers.IP-ն հրամանի հասցեն է
+	Fetch(registers Registers, command byte)
+	// CALL կամ TAILCALL ip հասցեում, որը կատարումը փոխանցում է target-ին
+	Call(ip int16, target int16)
+	// RET ip հասցեում, որը value արժեքով վերադառնում է target հասցեին
+	Return(ip int16, target int16, value int32)
+	// address հասցեից size բայթի ընթերցում
+	Read(address int32, size int)
+	// address հասցեում size բայթի գրառում
+	Write(address int32, size int)
+	// ներածման-արտածման գործողություն
+	IO(kind IOKind, value int32)
+	// ծուղակ՝ մինչև դրա մշակումը
+	Trap(trap *Trap)
+}
+
+// ներածման-արտածման գործողության տեսակը
+type IOKind int
+
+const (
+	IOInput      IOKind = iota // INPUT, value-ն կարդացված թիվն է
+	IOPrint                    // PRINT, value-ն արտածված թիվն է
+	IOErrorPrint               // EPRINT, value-ն արտածված թիվն է
+	IOSystemCall               // SYS n, value-ն կանչի համարն է
+)
+
+func (kind IOKind) String() string {
+	switch kind {
+	case IOInput:
+		return "INPUT"
+	case IOPrint:
+		return "PRINT"
+	case IOErrorPrint:
+		return "EPRINT"
+	}
+	return "SYS"
+}
+
+// մեքենայի ռեգիստրները
+type Registers struct {
+	IP, SP, FP int16
+	Mode       Mode // կատարման ռեժիմը
+	Thread     int  // ընթացիկ հոսքի համարը
+}
+
+// մեքենայի ռեգիստրների ընթացիկ արժեքները
+func (m *Machine) Registers() Registers {
+	return Registers{IP: m.ip, SP: m.sp, FP: m.fp, Mode: m.Mode(), Thread: m.thread}
+}
+
+// փոխել ընթացիկ հոսքի IP, SP և FP ռեգիստրները. ռեժիմն ու հոսքը չեն փոխվում
+func (m *Machine) SetRegisters(registers Registers) {
+	m.ip, m.sp, m.fp = registers.IP, registers.SP, registers.FP
+	m.current = m.ip
+}
+
+// տեղադրել observer դիտորդը. մի քանի դիտորդների դեպքում իրադարձությունները
+// ստանում են բոլորը՝ տեղադրման հերթականությամբ
+func WithObserver(observer Observer) Option {
+	return func(m *Machine) {
+		switch current := m.observer.(type) {
+		case nil:
+			m.observer = observer
+		case observers:
+			m.observer = append(current, observer)
+		default:
+			m.observer = observers{current, observer}
+		}
+	}
+}
+
+// Observer-ի դատարկ իրականացում, որը կարելի է ներդնել սեփական
+// դիտորդում և իրականացնել միայն անհրաժեշտ մեթոդները
+type NopObserver struct{}
+
+func (NopObserver) Fetch(Registers, byte)      {}
+func (NopObserver) Call(int16, int16)          {}
+func (NopObserver) Return(int16, int16, int32) {}
+func (NopObserver) Read(int32, int)            {}
+func (NopObserver) Write(int32, int)           {}
+func (NopObserver) IO(IOKind, int32)           {}
+func (NopObserver) Trap(*Trap)                 {}
+
+// մի քանի դիտորդ
+type observers []Observer
+
+func (o observers) Fetch(registers Registers, command byte) {
+	for _, observer := range o {
+		observer.Fetch(registers, command)
+	}
+}
+
+func (o observers) Call(ip int16, target int16) {
+	for _, observer := range o {
+		observer.Call(ip, target)
+	}
+}
+
+func (o observers) Return(ip int16, target int16, value int32) {
+	for _, observer := range o {
+		observer.Return(ip, target, value)
+	}
+}
+
+func (o observers) Read(address int32, size int) {
+	for _, observer := range o {
+		observer.Read(address, size)
+	}
+}
+
+func (o observers) Write(address int32, size int) {
+	for _, observer := range o {
+		observer.Write(address, size)
+	}
+}
+
+func (o observers) IO(kind IOKind, value int32) {
+	for _, observer := range o {
+		observer.IO(kind, value)
+	}
+}
+
+func (o observers) Trap(trap *Trap) {
+	for _, observer := range o {
+		observer.Trap(trap)
+	}
+}
+
+// դիտորդ, որն իրադարձությունները գրանցում է log/slog-ով. հրամանները,
+// կանչերն ու հիշողության դիմումները գրանցվում են Debug մակարդակով,
+// ներածումն ու արտածումը՝ Info, իսկ ծուղակները՝ Error մակարդակով
+type LogObserver struct {
+	logger *slog.Logger
+}
+
+func NewLogObserver(logger *slog.Logger) *LogObserver {
+	return &LogObserver{logger: logger}
+}
+
+func (o *LogObserver) log(level slog.Level, message string, attrs ...slog.Attr) {
+	o.logger.LogAttrs(context.Background(), level, message, attrs...)
+}
+
+func (o *LogObserver) Fetch(registers Registers, command byte) {
+	if !o.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	o.log(slog.LevelDebug, "հրաման",
+		slog.String("op", bytecode.Mnemonics[command&0x3F]),
+		slog.Int("ip", int(registers.IP)),
+		slog.Int("sp", int(registers.SP)),
+		slog.Int("fp", int(registers.FP)),
+		slog.String("mode", registers.Mode.String()),
+		slog.Int("thread", registers.Thread))
+}
+
+func (o *LogObserver) Call(ip int16, target int16) {
+	o.log(slog.LevelDebug, "կանչ", slog.Int("ip", int(ip)), slog.Int("target", int(target)))
+}
+
+func (o *LogObserver) Return(ip int16, target int16, value int32) {
+	o.log(slog.LevelDebug, "վերադարձ",
+		slog.Int("ip", int(ip)), slog.Int("target", int(target)), slog.Int("value", int(value)))
+}
+
+func (o *LogObserver) Read(address int32, size int) {
+	o.log(slog.LevelDebug, "ընթերցում", slog.Int("address", int(address)), slog.Int("size", size))
+}
+
+func (o *LogObserver) Write(address int32, size int) {
+	o.log(slog.LevelDebug, "գրառում", slog.Int("address", int(address)), slog.Int("size", size))
+}
+
+func (o *LogObserver) IO(kind IOKind, value int32) {
+	o.log(slog.LevelInfo, "ներածում-արտածում", slog.String("op", kind.String()), slog.Int("value", int(value)))
+}
+
+func (o *LogObserver) Trap(trap *Trap) {
+	o.log(slog.LevelError, "ծուղակ", slog.String("error", trap.Error()))
+}
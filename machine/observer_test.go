@@ -0,0 +1,86 @@
+package machine
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"svm/bytecode"
+	"testing"
+)
+
+// դիտորդ, որը հաշվում է իրադարձությունները
+type countingObserver struct {
+	NopObserver
+	fetches, calls, returns, writes int
+	printed                         []int32
+	traps                           []TrapCode
+}
+
+func (o *countingObserver) Fetch(Registers, byte)      { o.fetches++ }
+func (o *countingObserver) Call(int16, int16)          { o.calls++ }
+func (o *countingObserver) Return(int16, int16, int32) { o.returns++ }
+func (o *countingObserver) Write(int32, int)           { o.writes++ }
+func (o *countingObserver) Trap(trap *Trap)            { o.traps = append(o.traps, trap.Code) }
+func (o *countingObserver) IO(kind IOKind, value int32) {
+	if kind == IOPrint {
+		o.printed = append(o.printed, value)
+	}
+}
+
+func TestObserver(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithLabel(bytecode.Try, "handler")
+	builder.AddWithLabel(bytecode.Call, "seven")
+	builder.AddBasic(bytecode.Print)
+	builder.AddWithNumeric(bytecode.Push, 0)
+	builder.AddBasic(bytecode.Div)
+	builder.SetLabel("handler")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("seven")
+	builder.AddWithNumeric(bytecode.Push, 7)
+	builder.AddBasic(bytecode.Ret)
+	builder.Validate()
+
+	first, second := &countingObserver{}, &countingObserver{}
+	m := NewMachine(WithObserver(first), WithObserver(second), WithStdout(bytes.NewBufferString("")))
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	for _, o := range []*countingObserver{first, second} {
+		if o.fetches != 8 || o.calls != 1 || o.returns != 1 {
+			t.Errorf("Սխալ իրադարձություններ. %+v", o)
+		}
+		if len(o.printed) != 1 || o.printed[0] != 7 {
+			t.Errorf("Սպասվում է 7-ի արտածումը, բայց ստացվել է %v", o.printed)
+		}
+		if len(o.traps) != 1 || o.traps[0] != TrapDivisionByZero {
+			t.Errorf("Սպասվում է բաժանում զրոյի վրա, բայց ստացվել է %v", o.traps)
+		}
+		if o.writes == 0 {
+			t.Errorf("Սպասվում են ստեկի գրառումներ")
+		}
+	}
+}
+
+func TestLogObserver(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 7)
+	builder.AddBasic(bytecode.Print)
+	builder.AddBasic(bytecode.Halt)
+
+	output := bytes.NewBufferString("")
+	logger := slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	m := NewMachine(WithObserver(NewLogObserver(logger)), WithStdout(bytes.NewBufferString("")))
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+
+	for _, expected := range []string{"op=PUSH", "op=PRINT value=7", "op=HALT ip=6", "mode="} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Գրանցումներում սպասվում է %q.\n%s", expected, output)
+		}
+	}
+}
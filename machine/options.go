@@ -0,0 +1,48 @@
+package machine
+
+import (
+	"bufio"
+	"io"
+)
+
+// մեքենայի կարգավորում, որը տրվում է NewMachine-ին
+type Option func(*Machine)
+
+// ADD, SUB, MUL և NEG հրամանները գերլցման դեպքում առաջացնում են
+// TrapOverflow ծուղակը, ինչպես ADDO, SUBO, MULO և NEGO հրամանները,
+// իսկ DIV-ը և MOD-ը՝ MinInt32-ը -1-ի բաժանելիս
+func WithStrictArithmetic() Option {
+	return func(m *Machine) {
+		m.strict = true
+	}
+}
+
+// մեքենան կանգնեցնել TrapStepLimit ծուղակով steps հրաման կատարելուց
+// հետո։ Սահմանը կիրառվում է նաև բացառությունների և ծուղակների մշակիչների
+// հրամանների նկատմամբ
+func WithStepLimit(steps int) Option {
+	return func(m *Machine) {
+		m.stepLimit = steps
+	}
+}
+
+// INPUT հրամանի համար թվերը կարդալ reader-ից
+func WithStdin(reader io.Reader) Option {
+	return func(m *Machine) {
+		m.stdin = bufio.NewReader(reader)
+	}
+}
+
+// PRINT հրամանի արդյունքը գրել writer-ում
+func WithStdout(writer io.Writer) Option {
+	return func(m *Machine) {
+		m.stdout = writer
+	}
+}
+
+// EPRINT հրամանի արդյունքը գրել writer-ում
+func WithStderr(writer io.Writer) Option {
+	return func(m *Machine) {
+		m.stderr = writer
+	}
+}
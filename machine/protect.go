@@ -0,0 +1,48 @@
+package machine
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Հիշողության պաշտպանության քարտեզը նշում է այն բայթերը, որոնցում
+// ծրագիրը չի կարող գրել։ Load-ը պաշտպանում է ծրագրի բեռնված բայթերը,
+// իսկ Protect-ով կարելի է պաշտպանել նաև միայն կարդալու համար նախատեսված
+// տվյալները։ Պաշտպանված բայթում գրելու փորձը կանգնեցնում է մեքենան
+// TrapWriteProtected ծուղակով, որում նշված են գրող հրամանի և գրվող
+// բայթի հասցեները։ Վիրտուալ հիշողության դեպքում պաշտպանված են
+// ֆիզիկական հասցեները։
+
+// թույլատրել ծրագրի հրամանների փոփոխումը, օրինակ՝ ինքնափոփոխվող
+// կոդի ցուցադրման համար
+func WithSelfModifyingCode() Option {
+	return func(m *Machine) {
+		m.selfModifying = true
+	}
+}
+
+// address հասցեից սկսվող size բայթերը դարձնել միայն կարդալու համար
+func (m *Machine) Protect(address int32, size int32) error {
+	if address < 0 || size < 0 || address > int32(len(m.memory))-size {
+		return fmt.Errorf("Պաշտպանվող տիրույթը (%d, %d) դուրս է հիշողության սահմաններից", address, size)
+	}
+	if m.protected == nil {
+		m.protected = make([]bool, len(m.memory))
+	}
+	for i := address; i < address+size; i++ {
+		m.protected[i] = true
+	}
+	return nil
+}
+
+// ստուգել, որ physical հասցեից սկսվող size բայթերը պաշտպանված չեն.
+// addr-ը դրանց ծրագրին տեսանելի հասցեն է, իսկ ծուղակում նշվում է
+// առաջին պաշտպանված բայթի հասցեն
+func (m *Machine) checkWritable(addr int32, physical int32, size int32) {
+	if m.protected == nil {
+		return
+	}
+	if index := slices.Index(m.protected[physical:physical+size], true); index >= 0 {
+		m.trap(TrapWriteProtected, addr+int32(index))
+	}
+}
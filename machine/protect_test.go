@@ -0,0 +1,81 @@
+package machine
+
+import (
+	"errors"
+	"strings"
+	"svm/bytecode"
+	"testing"
+)
+
+func TestCodeWriteProtection(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 5)
+	builder.AddWithNumeric(bytecode.Push, 2)
+	builder.AddBasic(bytecode.Store)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	var trap *Trap
+	err := m.Run()
+	if !errors.As(err, &trap) || trap.Code != TrapWriteProtected {
+		t.Fatalf("Սպասվում է պաշտպանված հիշողության ծուղակ, բայց ստացվել է %v", err)
+	}
+	if trap.IP != 10 || trap.Address != 2 {
+		t.Errorf("Սպասվում է STORE-ի 10 և գրվող բայթի 2 հասցեները, բայց ստացվել է %d և %d", trap.IP, trap.Address)
+	}
+	if !strings.Contains(err.Error(), "(2)") {
+		t.Errorf("Սխալի հաղորդագրության մեջ սպասվում է հասցեն. %s", err)
+	}
+	if v := m.readByte(2); v != 0 {
+		t.Errorf("Ծրագրի բայթը չպետք է փոխվի, բայց ստացվել է %d", v)
+	}
+}
+
+func TestReadOnlyData(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddWithNumeric(bytecode.Push, 0x2004)
+	builder.AddBasic(bytecode.StoreB)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddWithNumeric(bytecode.Push, 0x2003)
+	builder.AddBasic(bytecode.StoreB)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	if err := m.Protect(0x2000, 4); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	var trap *Trap
+	if err := m.Run(); !errors.As(err, &trap) || trap.Code != TrapWriteProtected || trap.Address != 0x2003 {
+		t.Errorf("Սպասվում է պաշտպանված հիշողության ծուղակ, բայց ստացվել է %v", err)
+	}
+	if v := m.readByte(0x2004); v != 1 {
+		t.Errorf("Պաշտպանված տիրույթից դուրս սպասվում է 1, բայց ստացվել է %d", v)
+	}
+
+	var bounds *Trap
+	if err := m.Protect(MemorySize-2, 4); err == nil || errors.As(err, &bounds) {
+		t.Errorf("Հիշողության սահմաններից դուրս տիրույթի համար սպասվում է սխալ, բայց ստացվել է %v", err)
+	}
+}
+
+func TestPartiallyProtectedWrite(t *testing.T) {
+	// բառը գրվում է 0x1FFE-0x2001 բայթերում, որոնցից վերջին երկուսը պաշտպանված են
+	builder := bytecode.NewBuilder()
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddWithNumeric(bytecode.Push, 0x1FFE)
+	builder.AddBasic(bytecode.Store)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	if err := m.Protect(0x2000, 4); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	var trap *Trap
+	if err := m.Run(); !errors.As(err, &trap) || trap.Code != TrapWriteProtected || trap.Address != 0x2000 {
+		t.Errorf("Սպասվում է պաշտպանված 0x2000 բայթի ծուղակ, բայց ստացվել է %v", err)
+	}
+}
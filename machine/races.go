@@ -0,0 +1,175 @@
+package machine
+
+import "fmt"
+
+// Մրցավազքերի դետեկտորը հետևում է հոսքերի միջև «տեղի է ունեցել
+// ավելի վաղ» (happens-before) հարաբերությանը վեկտորական ժամացույցներով։
+// Հարաբերությունը ստեղծում են SPAWN-ը (ծնող հոսքի նախորդ գործողությունները
+// նոր հոսքի բոլոր գործողություններից առաջ են), ատոմար CAS և FETCHADD
+// հրամանները նույն հասցեի վրա և FENCE-երը։ Մրցավազք է համարվում նույն
+// բայթին երկու տարբեր հոսքերի ոչ ատոմար դիմումը, որոնցից գոնե մեկը
+// գրառում է, և որոնք չեն կարգավորված այդ հարաբերությամբ։
+
+// հիշողության դիմումը
+type Access struct {
+	Thread int   // հոսքի համարը
+	IP     int16 // դիմումը կատարած հրամանի հասցեն
+	Write  bool  // գրառում է
+}
+
+func (a Access) String() string {
+	kind := "ընթերցում"
+	if a.Write {
+		kind = "գրառում"
+	}
+	return fmt.Sprintf("%s %d հոսքում [%04x]", kind, a.Thread, a.IP)
+}
+
+// տվյալների մրցավազք Address հասցեում
+type Race struct {
+	Address  int32  // դիմման հասցեն
+	Previous Access // ավելի վաղ դիմումը
+	Current  Access // դրա հետ չկարգավորված դիմումը
+}
+
+func (r Race) String() string {
+	return fmt.Sprintf("մրցավազք %d հասցեում. %s և %s", r.Address, r.Previous, r.Current)
+}
+
+// միացնել մրցավազքերի դետեկտորը
+func WithRaceDetector() Option {
+	return func(m *Machine) {
+		m.races = &raceDetector{
+			clocks:    []vectorClock{{1}},
+			locations: map[int32]vectorClock{},
+			shadow:    map[int32]*shadowCell{},
+			reported:  map[int32]bool{},
+		}
+	}
+}
+
+// հայտնաբերված մրցավազքերը՝ ամեն հասցեի համար առաջինը. Address-ը
+// մրցավազքի մեջ գտնվող դիմման սկզբի հասցեն է
+func (m *Machine) Races() []Race {
+	if m.races == nil {
+		return nil
+	}
+	return m.races.races
+}
+
+// անջատել դետեկտորն ու դիտորդը մեքենայի ներքին դիմումների համար,
+// օրինակ՝ աղբահավաքի. վերադարձնում է դրանք նորից միացնող ֆունկցիան
+func (m *Machine) untracked() func() {
+	races, observer := m.races, m.observer
+	m.races, m.observer = nil, nil
+	return func() { m.races, m.observer = races, observer }
+}
+
+// վեկտորական ժամացույց. ամեն հոսքի համար դրա տրամաբանական ժամանակը
+type vectorClock []int
+
+func (v vectorClock) get(thread int) int {
+	if thread < len(v) {
+		return v[thread]
+	}
+	return 0
+}
+
+// v-ին միավորել other-ը՝ ամեն բաղադրիչի առավելագույնով
+func (v vectorClock) join(other vectorClock) vectorClock {
+	for len(v) < len(other) {
+		v = append(v, 0)
+	}
+	for i, t := range other {
+		v[i] = max(v[i], t)
+	}
+	return v
+}
+
+// դիմման ժամանակը՝ հոսքն ու դրա ժամացույցի արժեքը
+type epoch struct {
+	access Access
+	clock  int
+}
+
+// մեկ բայթի դիմումների պատմությունը
+type shadowCell struct {
+	write *epoch        // վերջին գրառումը
+	reads map[int]epoch // ամեն հոսքի վերջին ընթերցումը վերջին գրառումից հետո
+}
+
+type raceDetector struct {
+	clocks    []vectorClock         // ամեն հոսքի ժամացույցը
+	fences    vectorClock           // FENCE-երի ընդհանուր ժամացույցը
+	locations map[int32]vectorClock // ատոմար դիմումների ժամացույցներն ըստ հասցեի
+	shadow    map[int32]*shadowCell
+	races     []Race
+	reported  map[int32]bool
+}
+
+// thread հոսքի համար ստուգել address-ից սկսվող size բայթերին դիմումը
+func (d *raceDetector) access(thread int, address int32, size int, write bool, ip int16) {
+	clock := d.clocks[thread]
+	current := Access{Thread: thread, IP: ip, Write: write}
+	for a := address; a < address+int32(size); a++ {
+		cell := d.shadow[a]
+		if cell == nil {
+			cell = &shadowCell{}
+			d.shadow[a] = cell
+		}
+
+		if w := cell.write; w != nil && w.access.Thread != thread && w.clock > clock.get(w.access.Thread) {
+			d.report(address, w.access, current)
+		}
+		if write {
+			for _, r := range cell.reads {
+				if r.access.Thread != thread && r.clock > clock.get(r.access.Thread) {
+					d.report(address, r.access, current)
+				}
+			}
+			cell.write = &epoch{current, clock[thread]}
+			cell.reads = nil
+		} else {
+			if cell.reads == nil {
+				cell.reads = map[int]epoch{}
+			}
+			cell.reads[thread] = epoch{current, clock[thread]}
+		}
+	}
+}
+
+func (d *raceDetector) report(address int32, previous, current Access) {
+	if d.reported[address] {
+		return
+	}
+	d.reported[address] = true
+	d.races = append(d.races, Race{Address: address, Previous: previous, Current: current})
+}
+
+// parent հոսքը ստեղծել է child հոսքը
+func (d *raceDetector) spawn(parent, child int) {
+	for len(d.clocks) <= child {
+		d.clocks = append(d.clocks, nil)
+	}
+	clock := vectorClock{}.join(d.clocks[parent])
+	for len(clock) <= child {
+		clock = append(clock, 0)
+	}
+	clock[child] = 1
+	d.clocks[child] = clock
+	d.clocks[parent][parent]++
+}
+
+// thread հոսքի ատոմար դիմումը address հասցեին
+func (d *raceDetector) synchronize(thread int, address int32) {
+	d.clocks[thread] = d.clocks[thread].join(d.locations[address])
+	d.locations[address] = d.locations[address].join(d.clocks[thread])
+	d.clocks[thread][thread]++
+}
+
+// thread հոսքի FENCE-ը
+func (d *raceDetector) fence(thread int) {
+	d.clocks[thread] = d.clocks[thread].join(d.fences)
+	d.fences = d.fences.join(d.clocks[thread])
+	d.clocks[thread][thread]++
+}
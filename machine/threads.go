@@ -0,0 +1,202 @@
+package machine
+
+import "math/rand/v2"
+
+// Մեքենան կարող է կատարել մի քանի հոսքեր (threads), որոնք ունեն
+// ընդհանուր հիշողություն, բայց առանձին IP, SP, FP ռեգիստրներ ու
+// առանձին ստեկներ։ Ծրագիրը սկսվում է մեկ՝ 0 համարի հոսքով, իսկ նոր
+// հոսքերը ստեղծվում են SPAWN հրամանով։ Պլանավորիչը հոսքերը փոխում է
+// ամեն quantum հրամանից հետո՝ հերթով կամ, seed տրված լինելու դեպքում,
+// պատահական, բայց վերարտադրելի հաջորդականությամբ։ HALT-ը կանգնեցնում
+// է միայն ընթացիկ հոսքը, իսկ մեքենան կանգնում է, երբ ավարտվել են
+// բոլոր հոսքերը։
+//
+// Մեքենան հրամանները կատարում է մեկը մյուսի հետևից, ուստի ամեն հրաման
+// ատոմար է, իսկ հիշողության մոդելը՝ հաջորդական համաձայնեցված։
+
+// լռելյայն քվանտը՝ հրամանների քանակը, որից հետո պլանավորիչը փոխում է հոսքը
+const defaultQuantum = 100
+
+// հոսքի ռեգիստրները, երբ այն չի կատարվում
+type thread struct {
+	ip, sp, fp, hp int16
+	base           int16 // ստեկի սկիզբը
+	halted         bool  // հոսքն ավարտվել է
+}
+
+// հոսքերը փոխել ամեն quantum հրամանից հետո՝ հաջորդ հոսքն ընտրելով
+// seed-ով որոշված պատահական հաջորդականությամբ
+func WithScheduler(quantum int, seed uint64) Option {
+	return func(m *Machine) {
+		m.quantum = max(quantum, 1)
+		m.random = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// ընթացիկ հոսքի համարը
+func (m *Machine) Thread() int {
+	return m.thread
+}
+
+// հոսքերի քանակը՝ ներառյալ ավարտվածները
+func (m *Machine) Threads() int {
+	return max(len(m.threads), 1)
+}
+
+// SPAWN label. ստեկից վերցնել նոր հոսքի արգումենտը և ստեկի հասցեն,
+// ստեղծել label հասցեից սկսվող հոսք, որի ստեկի գագաթին արգումենտն է,
+// և ստեկում գրել նոր հոսքի համարը
+func (m *Machine) spawn() {
+	address := m.readCodeWord(m.ip)
+	m.ip += 2
+	argument := m.basicPop()
+	stack := int16(m.basicPop())
+
+	// արգումենտը գրել նոր հոսքի ստեկում
+	m.write(stack, argument)
+
+	if m.threads == nil {
+		m.threads = []*thread{{}}
+	}
+	id := len(m.threads)
+	m.threads = append(m.threads, &thread{
+		ip:   int16(address),
+		sp:   stack + 4,
+		fp:   stack,
+		hp:   noHandler,
+		base: stack,
+	})
+	if m.races != nil {
+		m.races.spawn(m.thread, id)
+	}
+	m.basicPush(int32(id))
+}
+
+// CAS. ստեկից վերցնել հասցեն, սպասվող և նոր արժեքները. եթե հասցեում
+// սպասվող արժեքն է, ապա այն փոխարինել նորով ու ստեկում գրել 1, հակառակ
+// դեպքում՝ 0
+func (m *Machine) compareAndSwap() {
+	value := m.basicPop()
+	expected := m.basicPop()
+	address := m.address(m.basicPop(), 4)
+
+	m.atomic = true
+	defer func() { m.atomic = false }()
+	if m.races != nil {
+		m.races.synchronize(m.thread, int32(address))
+	}
+	if m.read(address) != expected {
+		m.basicPush(0)
+		return
+	}
+	m.write(address, value)
+	m.basicPush(1)
+}
+
+// FETCHADD. ստեկից վերցնել հասցեն ու գումարելին, հասցեի արժեքին
+// գումարել այն և ստեկում գրել նախկին արժեքը
+func (m *Machine) fetchAndAdd() {
+	delta := m.basicPop()
+	address := m.address(m.basicPop(), 4)
+
+	m.atomic = true
+	defer func() { m.atomic = false }()
+	if m.races != nil {
+		m.races.synchronize(m.thread, int32(address))
+	}
+	value := m.read(address)
+	m.write(address, value+delta)
+	m.basicPush(value)
+}
+
+// FENCE. հիշողության պատնեշ. հաջորդական համաձայնեցված մոդելում այն
+// ոչինչ չի փոխում, բայց մրցավազքերի դետեկտորի համար կարգավորում է
+// պատնեշից առաջ և հետո կատարված դիմումները մյուս հոսքերի պատնեշների հետ
+func (m *Machine) fence() {
+	if m.races != nil {
+		m.races.fence(m.thread)
+	}
+}
+
+// HALT-ից կամ հրամանից հետո ընտրել հաջորդ կատարվող հոսքը. վերադարձնում
+// է false, եթե բոլոր հոսքերն ավարտվել են
+func (m *Machine) schedule(running bool) bool {
+	if m.threads == nil {
+		return running
+	}
+	if !running {
+		m.threads[m.thread].halted = true
+	}
+
+	runnable := []int{}
+	for id, t := range m.threads {
+		if !t.halted {
+			runnable = append(runnable, id)
+		}
+	}
+	if len(runnable) == 0 {
+		return false
+	}
+
+	next := m.thread
+	switch {
+	case m.choose != nil:
+		next = m.choose(runnable, m.thread)
+	case !running:
+		m.ticks = 0
+		next = m.pick(runnable)
+	default:
+		m.ticks++
+		if m.ticks >= m.quantum {
+			m.ticks = 0
+			next = m.pick(runnable)
+		}
+	}
+	m.switchTo(next)
+	return true
+}
+
+// ընտրել հաջորդ հոսքը. հերթով կամ պատահական
+func (m *Machine) pick(runnable []int) int {
+	if m.random != nil {
+		return runnable[m.random.IntN(len(runnable))]
+	}
+	for _, id := range runnable {
+		if id > m.thread {
+			return id
+		}
+	}
+	return runnable[0]
+}
+
+// պահել ընթացիկ հոսքի ռեգիստրները և վերականգնել id հոսքինը
+func (m *Machine) switchTo(id int) {
+	if id == m.thread {
+		return
+	}
+	current := m.threads[m.thread]
+	current.ip, current.sp, current.fp, current.hp = m.ip, m.sp, m.fp, m.hp
+	current.base = m.base
+
+	next := m.threads[id]
+	m.ip, m.sp, m.fp, m.hp = next.ip, next.sp, next.fp, next.hp
+	m.base = next.base
+	m.thread = id
+}
+
+// բոլոր չավարտված հոսքերի ստեկները՝ սկիզբը, գագաթը և FP-ն
+func (m *Machine) stacks() [][3]int16 {
+	if m.threads == nil {
+		return [][3]int16{{m.base, m.sp, m.fp}}
+	}
+	stacks := [][3]int16{}
+	for id, t := range m.threads {
+		switch {
+		case id == m.thread:
+			stacks = append(stacks, [3]int16{m.base, m.sp, m.fp})
+		case !t.halted:
+			stacks = append(stacks, [3]int16{t.base, t.sp, t.fp})
+		}
+	}
+	return stacks
+}
@@ -0,0 +1,174 @@
+package machine
+
+import (
+	"errors"
+	"svm/bytecode"
+	"testing"
+)
+
+// main-ը ստեղծում է worker-ի երկու հոսք 0x3000 և 0x3400 ստեկերով
+// ու ավարտվում
+func spawnWorkers(builder *bytecode.Builder, argument int32) {
+	for _, stack := range []int32{0x3000, 0x3400} {
+		builder.AddWithNumeric(bytecode.Push, stack)
+		builder.AddWithNumeric(bytecode.Push, argument)
+		builder.AddWithLabel(bytecode.Spawn, "worker")
+		builder.AddWithAddress(bytecode.Pop, bytecode.StackPointer, -4)
+	}
+	builder.AddBasic(bytecode.Halt)
+}
+
+func TestThreads(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	spawnWorkers(builder, 50)
+	// worker-ը 0x2000 հասցեի արժեքին ատոմար գումարում է 1 այնքան
+	// անգամ, որքան իր արգումենտն է
+	builder.SetLabel("worker")
+	builder.AddWithNumeric(bytecode.Push, 0x2000)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.FetchAdd)
+	builder.AddWithAddress(bytecode.Pop, bytecode.StackPointer, -4)
+	builder.AddWithAddress(bytecode.Push, bytecode.StackPointer, -4)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.Sub)
+	builder.AddWithAddress(bytecode.Pop, bytecode.StackPointer, -8)
+	builder.AddWithAddress(bytecode.Push, bytecode.StackPointer, -4)
+	builder.AddWithLabel(bytecode.Jz, "done")
+	builder.AddWithLabel(bytecode.Jump, "worker")
+	builder.SetLabel("done")
+	builder.AddBasic(bytecode.Halt)
+	builder.Validate()
+
+	m := NewMachine(WithScheduler(3, 42), WithRaceDetector())
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if v := m.read(0x2000); v != 100 {
+		t.Errorf("Սպասվում է 100, բայց ստացվել է %d", v)
+	}
+	if m.Threads() != 3 {
+		t.Errorf("Սպասվում է 3 հոսք, բայց ստացվել է %d", m.Threads())
+	}
+	if races := m.Races(); len(races) != 0 {
+		t.Errorf("Ատոմար դիմումների համար մրցավազք չի սպասվում. %v", races)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	for _, expected := range []int32{5, 0} {
+		builder.AddWithNumeric(bytecode.Push, 0x2000)
+		builder.AddWithNumeric(bytecode.Push, expected)
+		builder.AddWithNumeric(bytecode.Push, 7)
+		builder.AddBasic(bytecode.Cas)
+	}
+	builder.AddBasic(bytecode.Fence)
+	builder.AddBasic(bytecode.Halt)
+
+	m := NewMachine()
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if second, first := m.basicPop(), m.basicPop(); first != 0 || second != 1 {
+		t.Errorf("Սպասվում է 0 և 1, բայց ստացվել է %d և %d", first, second)
+	}
+	if v := m.read(0x2000); v != 7 {
+		t.Errorf("Սպասվում է 7, բայց ստացվել է %d", v)
+	}
+}
+
+func TestDataRace(t *testing.T) {
+	builder := bytecode.NewBuilder()
+	spawnWorkers(builder, 0)
+	// 0x2000 հասցեի արժեքին 1 գումարել առանց համաժամեցման
+	builder.SetLabel("worker")
+	builder.AddWithNumeric(bytecode.Push, 0x2000)
+	builder.AddBasic(bytecode.Load)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.Add)
+	builder.AddWithNumeric(bytecode.Push, 0x2000)
+	builder.AddBasic(bytecode.Store)
+	builder.AddBasic(bytecode.Halt)
+	builder.Validate()
+
+	m := NewMachine(WithRaceDetector())
+	m.Load(builder.Bytes())
+	if err := m.Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	races := m.Races()
+	if len(races) == 0 {
+		t.Fatalf("Սպասվում է մրցավազք")
+	}
+	if race := races[0]; race.Address != 0x2000 || race.Previous.Thread == race.Current.Thread {
+		t.Errorf("Սխալ մրցավազք. %v", race)
+	}
+}
+
+// worker-ը FENCE-երով պաշտպանված, բայց ոչ ատոմար կերպով 0x2000
+// հասցեի արժեքին գումարում է 1, իսկ երկրորդն ավարտվող հոսքը
+// ստուգում է, որ արժեքը 2 է
+func racyIncrement() []byte {
+	builder := bytecode.NewBuilder()
+	spawnWorkers(builder, 0)
+	builder.SetLabel("worker")
+	builder.AddBasic(bytecode.Fence)
+	builder.AddWithNumeric(bytecode.Push, 0x2000)
+	builder.AddBasic(bytecode.Load)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.Add)
+	builder.AddWithNumeric(bytecode.Push, 0x2000)
+	builder.AddBasic(bytecode.Store)
+	builder.AddBasic(bytecode.Fence)
+	builder.AddWithNumeric(bytecode.Push, 0x2004)
+	builder.AddWithNumeric(bytecode.Push, 1)
+	builder.AddBasic(bytecode.FetchAdd)
+	builder.AddWithLabel(bytecode.Jz, "first")
+	builder.AddBasic(bytecode.Fence)
+	builder.AddWithNumeric(bytecode.Push, 0x2000)
+	builder.AddBasic(bytecode.Load)
+	builder.AddWithNumeric(bytecode.Push, 2)
+	builder.AddBasic(bytecode.Eq)
+	builder.AddWithLabel(bytecode.Jz, "fail")
+	builder.SetLabel("first")
+	builder.AddBasic(bytecode.Halt)
+	builder.SetLabel("fail")
+	builder.AddWithNumeric(bytecode.Push, 99)
+	builder.AddBasic(bytecode.Throw)
+	builder.Validate()
+	return builder.Bytes()
+}
+
+func TestExplore(t *testing.T) {
+	code := racyIncrement()
+
+	result := Explore(code, Exploration{Preemptions: 0})
+	if result.Failure != nil {
+		t.Fatalf("Առանց ընդհատումների ձախողում չի սպասվում. %+v", result.Failure)
+	}
+
+	result = Explore(code, Exploration{Preemptions: 1})
+	failure := result.Failure
+	if failure == nil {
+		t.Fatalf("Սպասվում է ձախողում, կատարումներ՝ %d", result.Runs)
+	}
+	var trap *Trap
+	if !errors.As(failure.Err, &trap) || trap.Code != TrapUnhandledException {
+		t.Errorf("Սպասվում է չմշակված բացառություն, բայց ստացվել է %v", failure.Err)
+	}
+
+	// ձախողված կատարումը վերարտադրվում է իր հերթագայությամբ
+	m := NewMachine(WithSchedule(failure.Schedule))
+	m.Load(code)
+	if err := m.Run(); !errors.As(err, &trap) || trap.Code != TrapUnhandledException {
+		t.Errorf("Սպասվում է չմշակված բացառություն, բայց ստացվել է %v", err)
+	}
+
+	// մրցավազքերը հայտնաբերվում են արդեն մեկ ընդհատումով
+	result = Explore(code, Exploration{Preemptions: 1, Races: true})
+	if result.Failure == nil || len(result.Failure.Races) == 0 {
+		t.Errorf("Սպասվում է մրցավազք, բայց ստացվել է %+v", result.Failure)
+	}
+}
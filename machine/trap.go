@@ -0,0 +1,114 @@
+package machine
+
+import "fmt"
+
+// ծուղակի (trap) տեսակը
+type TrapCode int32
+
+const (
+	_                      TrapCode = iota
+	TrapInvalidOpcode               // անծանոթ գործողության կոդ
+	TrapMemoryBounds                // դիմում հիշողության սահմաններից դուրս
+	TrapDivisionByZero              // բաժանում զրոյի վրա
+	TrapUnhandledException          // THROW-ի արժեքը մշակող չունի
+	TrapOverflow                    // նշանով ամբողջ թվի գերլցում
+	TrapOutOfMemory                 // կույտում տեղ չկա
+	TrapStackOverflow               // ստեկը հասել է իր սահմանին
+	TrapInvalidSystemCall           // անծանոթ համակարգային կանչ
+	TrapPageFault                   // էջը բացակա է կամ պաշտպանված է գրելուց
+	TrapPrivileged                  // միջուկի հրաման օգտագործողի ռեժիմում
+	TrapWriteProtected              // գրառում պաշտպանված հիշողությունում
+	TrapStepLimit                   // կատարվել է քայլերի թույլատրելի քանակը
+	TrapAssertion                   // ASSERT-ի պայմանը 0 է
+)
+
+var trapMessages = map[TrapCode]string{
+	TrapInvalidOpcode:      "անծանոթ գործողության կոդ",
+	TrapMemoryBounds:       "դիմում հիշողության սահմաններից դուրս",
+	TrapDivisionByZero:     "բաժանում զրոյի վրա",
+	TrapUnhandledException: "չմշակված բացառություն",
+	TrapOverflow:           "ամբողջ թվի գերլցում",
+	TrapOutOfMemory:        "կույտում տեղ չկա",
+	TrapStackOverflow:      "ստեկի գերլցում",
+	TrapInvalidSystemCall:  "անծանոթ համակարգային կանչ",
+	TrapPageFault:          "էջային խափանում",
+	TrapPrivileged:         "արգելված հրաման օգտագործողի ռեժիմում",
+	TrapWriteProtected:     "գրառում պաշտպանված հիշողությունում",
+	TrapStepLimit:          "քայլերի սահմանը սպառված է",
+	TrapAssertion:          "պնդումը ձախողվեց",
+}
+
+func (c TrapCode) String() string {
+	if message, ok := trapMessages[c]; ok {
+		return message
+	}
+	return fmt.Sprintf("Trap(%d)", int32(c))
+}
+
+// Trap-ը նկարագրում է այն սխալը, որի պատճառով մեքենան
+// չի կարող շարունակել ծրագրի կատարումը
+type Trap struct {
+	Code    TrapCode // ծուղակի տեսակը
+	IP      int16    // սխալն առաջացրած հրամանի հասցեն
+	Address int32    // հիշողության հասցեն, եթե սխալը դրա հետ է կապված
+	Value   int32    // չմշակված բացառության արժեքը
+	Mode    Mode     // մեքենայի ռեժիմը ծուղակի պահին
+	Thread  int      // ծուղակն առաջացրած հոսքի համարը
+}
+
+func (t *Trap) Error() string {
+	message := fmt.Sprintf("ՍԽԱԼ [%04x]: %s", t.IP, t.Code)
+	switch t.Code {
+	case TrapMemoryBounds, TrapPageFault, TrapWriteProtected:
+		message = fmt.Sprintf("%s (%d)", message, t.Address)
+	case TrapUnhandledException:
+		message = fmt.Sprintf("%s (%d)", message, t.Value)
+	}
+	if t.Mode == ModeUser {
+		message += ", " + t.Mode.String()
+	}
+	if t.Thread != 0 {
+		message += fmt.Sprintf(", հոսք %d", t.Thread)
+	}
+	return message
+}
+
+// ընդհատել ընթացիկ հրամանի կատարումը
+func (m *Machine) trap(code TrapCode, address int32) {
+	panic(&Trap{Code: code, IP: m.current, Address: address, Mode: m.Mode(), Thread: m.thread})
+}
+
+// կատարել action-ը՝ դրա ընթացքում առաջացած ծուղակը վերադարձնելով որպես սխալ
+func (m *Machine) protect(action func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			trap, ok := r.(*Trap)
+			if !ok {
+				panic(r)
+			}
+			err = trap
+		}
+	}()
+
+	action()
+	return nil
+}
+
+// ծուղակը փոխանցել ամենամոտ TRY մշակիչին՝ որպես բացառություն,
+// որի արժեքը ծուղակի կոդի բացասումն է։ Էջային խափանումները, արգելված
+// հրամանները և այն ծուղակները, որոնց համար TRY մշակիչ չկա, փոխանցվում
+// են TVEC մշակիչին։ Քայլերի սահմանի սպառումը և ձախողված պնդումը չեն
+// մշակվում, որպեսզի ծրագիրը չկարողանա թաքցնել դրանք
+func (m *Machine) catch(trap *Trap) error {
+	if trap.Code == TrapStepLimit || trap.Code == TrapAssertion {
+		return trap
+	}
+	if trap.Code != TrapUnhandledException && trap.Code != TrapPageFault &&
+		trap.Code != TrapPrivileged && m.hp != noHandler {
+		return m.protect(func() { m.throw(-int32(trap.Code)) })
+	}
+	if m.control[ControlVector] != 0 {
+		return m.protect(func() { m.interrupt(trap) })
+	}
+	return trap
+}
@@ -1,37 +1,383 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"os"
+	"strings"
 	"svm/assembler"
+	"svm/coverage"
+	"svm/dap"
+	"svm/fuzzer"
 	"svm/machine"
+	"svm/repl"
+	"svm/rsp"
 )
 
-func execute(input string) {
+// ծրագրի ավարտի կոդերը, երբ ծրագիրն ինքը չի որոշել այն HALT n-ով
+const (
+	exitFailure = 1 // ֆայլը բացելու կամ ասեմբլերի սխալ
+	exitTrap    = 2 // մեքենան կանգնել է ծուղակի պատճառով
+)
+
+// run հրամանի կարգավորումները
+type settings struct {
+	dir           string // ծրագրին հասանելի ֆայլերի պանակը, եթե դատարկ չէ
+	harvard       bool   // կատարել հարվարդյան ռեժիմում
+	selfModifying bool   // թույլատրել ծրագրի հրամանների փոփոխումը
+	trace         bool   // կատարման իրադարձությունները գրանցել սխալների հոսքում
+	core          string // ծուղակի դեպքում մեքենայի վիճակը գրել այս ֆայլում, եթե դատարկ չէ
+}
+
+// կարգավորումներին համապատասխան մեքենայի կարգավորումները
+func (s settings) options() ([]machine.Option, error) {
+	options := []machine.Option{}
+	if s.dir != "" {
+		fsys, err := machine.DirFS(s.dir)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, machine.WithFileSystem(fsys))
+	}
+	if s.harvard {
+		options = append(options, machine.WithHarvard())
+	}
+	if s.selfModifying {
+		options = append(options, machine.WithSelfModifyingCode())
+	}
+	if s.trace {
+		handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+		options = append(options, machine.WithObserver(machine.NewLogObserver(slog.New(handler))))
+	}
+	return options, nil
+}
+
+// ասեմբլացնել ու կատարել input ֆայլում գրված ծրագիրը՝ args
+// արգումենտներով և s կարգավորումներով, և վերադարձնել ավարտի կոդը
+func execute(input string, args []string, s settings) int {
 	_, err := os.Stat(input)
 	if err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("Ֆայլը գոյություն չունի կամ հասանելի չէ. %s\n", input)
+			fmt.Fprintf(os.Stderr, "Ֆայլը գոյություն չունի կամ հասանելի չէ. %s\n", input)
 		}
-		return
+		return exitFailure
 	}
 
-	bytes, err := assembler.Assemble(input)
+	program, err := assembler.AssembleProgram(input)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+
+	options, err := s.options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+
+	vm := machine.NewMachine(options...)
+	defer vm.Close()
+	vm.LoadProgram(program)
+	err = vm.SetArguments(args)
+	if err == nil {
+		err = vm.Run()
 	}
 
+	var trap *machine.Trap
+	if errors.As(err, &trap) {
+		fmt.Fprintln(os.Stderr, err.Error())
+		for k, frame := range vm.Backtrace() {
+			fmt.Fprintf(os.Stderr, "  #%d %s\n", k, frame)
+		}
+		if s.core != "" {
+			if err := writeFile(s.core, vm.Core(trap).Write); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+			}
+		}
+		return exitTrap
+	}
+
+	return int(vm.ExitCode())
+}
+
+// cover հրամանի կարգավորումները
+type coverSettings struct {
+	profile string // ծածկույթը կուտակող ֆայլը, եթե դատարկ չէ
+	html    string // HTML հաշվետվության ֆայլը, եթե դատարկ չէ
+	lcov    string // LCOV հաշվետվության ֆայլը, եթե դատարկ չէ
+	steps   int    // մեկ կատարման քայլերի առավելագույն քանակը
+}
+
+// կատարել input ֆայլում գրված ծրագիրը ամեն ներածման ֆայլով (կամ
+// ստանդարտ ներածմամբ, եթե inputs-ը դատարկ է), միավորել կատարումների
+// ծածկույթը և արտածել տեքստային հաշվետվությունը
+func cover(input string, inputs []string, c coverSettings) int {
+	program, err := assembler.AssembleProgram(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+	source, err := os.ReadFile(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+
+	profile := coverage.NewProfile(input, program)
+	if c.profile != "" {
+		if err := mergeProfile(profile, c.profile); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return exitFailure
+		}
+	}
+
+	if len(inputs) == 0 {
+		inputs = []string{""}
+	}
+	code := 0
+	for _, name := range inputs {
+		stdin := os.Stdin
+		if name != "" {
+			stdin, err = os.Open(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Չհաջողվեց բացել ներածման ֆայլը. %s\n", name)
+				return exitFailure
+			}
+		}
+		vm := machine.NewMachine(
+			machine.WithStdin(stdin),
+			machine.WithStdout(io.Discard),
+			machine.WithObserver(profile.Recorder(program)),
+			machine.WithStepLimit(c.steps))
+		vm.Load(program.Code)
+		// անվերջ ցիկլով ծրագիրը չպետք է կախի հաշվետվությունը. ծածկույթը
+		// հաշվվում է մինչև ընդհատումը
+		var trap *machine.Trap
+		if err := vm.Run(); errors.As(err, &trap) && trap.Code == machine.TrapStepLimit {
+			fmt.Fprintf(os.Stderr, "%s: կատարումն ընդհատվեց %d քայլից հետո\n", name, c.steps)
+			code = exitTrap
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			code = exitTrap
+		}
+		vm.Close()
+		if stdin != os.Stdin {
+			stdin.Close()
+		}
+	}
+
+	reports := []struct {
+		file  string
+		write func(io.Writer) error
+	}{
+		{c.profile, profile.Write},
+		{c.html, func(w io.Writer) error { return profile.WriteHTML(w, string(source)) }},
+		{c.lcov, profile.WriteLCOV},
+	}
+	for _, report := range reports {
+		if report.file == "" {
+			continue
+		}
+		if err := writeFile(report.file, report.write); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return exitFailure
+		}
+	}
+	profile.WriteText(os.Stdout, string(source))
+	return code
+}
+
+// profile-ին ավելացնել file ֆայլում պահված ծածկույթը, եթե ֆայլը կա
+func mergeProfile(profile *coverage.Profile, file string) error {
+	input, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	saved, err := coverage.ReadProfile(input)
+	if err != nil {
+		return err
+	}
+	return profile.Merge(saved)
+}
+
+// ստեղծել file ֆայլը և գրել դրանում write-ով
+func writeFile(file string, write func(io.Writer) error) error {
+	output, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(output); err != nil {
+		output.Close()
+		return err
+	}
+	return output.Close()
+}
+
+// ֆազել input ֆայլում գրված ծրագիրը և արտածել ծուղակով ավարտվող
+// կատարումների պարզեցված ներածումները
+func fuzz(input string, config fuzzer.Config) int {
+	program, err := assembler.AssembleProgram(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+
+	result := fuzzer.Fuzz(input, program, config)
+	fmt.Printf("Կատարումներ՝ %d։ %s\n", result.Runs, result.Coverage.Summary())
+	for _, finding := range result.Findings {
+		fmt.Println(finding.Trap)
+		values := make([]string, len(finding.Input))
+		for k, value := range finding.Input {
+			values[k] = fmt.Sprint(value)
+		}
+		fmt.Printf("  ներածում՝ %s\n", strings.Join(values, " "))
+		for k, frame := range finding.Backtrace {
+			fmt.Printf("  #%d %s\n", k, frame)
+		}
+	}
+	if len(result.Findings) > 0 {
+		return exitTrap
+	}
+	return 0
+}
+
+// ասեմբլացնել input ծրագիրը և սպասել GDB-ի միացմանը listen հասցեում.
+// ծրագիրը կատարվում է հաճախորդի հրամաններով
+func debug(input string, args []string, listen string) int {
+	program, err := assembler.AssembleProgram(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
 	vm := machine.NewMachine()
-	vm.Load(bytes)
-	vm.Run()
+	defer vm.Close()
+	vm.LoadProgram(program)
+	if err := vm.SetArguments(args); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+	defer listener.Close()
+	fmt.Fprintf(os.Stderr, "Սպասում եմ GDB-ին %s հասցեում\n", listener.Addr())
+	connection, err := listener.Accept()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+	defer connection.Close()
+	if err := rsp.NewServer(vm).Serve(connection); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+	return int(vm.ExitCode())
 }
 
 func main() {
 	if len(os.Args) == 1 {
 		fmt.Println("Ստեկային վիրտուալ մեքենա, v0.0.1")
+		fmt.Println("Օգտագործումը. svm [run [-dir պանակ] [-harvard] [-self-modifying] [-trace] [-core ֆայլ]] ծրագիր.asm [արգումենտներ...]")
+		fmt.Println("             svm cover [-profile ֆայլ] [-html ֆայլ] [-lcov ֆայլ] [-steps n] ծրագիր.asm [ներածման ֆայլեր...]")
+		fmt.Println("             svm core ֆայլ.core [bt | dis [n] | mem հասցե [քանակ] | frame n]")
+		fmt.Println("             svm fuzz [-seed n] [-runs n] [-steps n] [-inputs n] ծրագիր.asm")
+		fmt.Println("             svm dap")
+		fmt.Println("             svm repl")
+		fmt.Println("             svm gdb [-listen հասցե] ծրագիր.asm [արգումենտներ...]")
+		return
+	}
+
+	args := os.Args[1:]
+	if args[0] == "core" {
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Նշված չէ core ֆայլը։")
+			os.Exit(exitFailure)
+		}
+		os.Exit(inspect(args[1], args[2:]))
+	}
+	if args[0] == "dap" {
+		// կարգաբերիչը հաղորդակցվում է ստանդարտ հոսքերով
+		if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(exitFailure)
+		}
 		return
 	}
+	if args[0] == "repl" {
+		if err := repl.New(os.Stdin, os.Stdout).Run(); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(exitFailure)
+		}
+		return
+	}
+	if args[0] == "gdb" {
+		flags := flag.NewFlagSet("gdb", flag.ExitOnError)
+		listen := flags.String("listen", "localhost:1234", "GDB-ի միացման հասցեն")
+		flags.Parse(args[1:])
+		args = flags.Args()
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Նշված չէ ծրագրի ֆայլը։")
+			os.Exit(exitFailure)
+		}
+		os.Exit(debug(args[0], args[1:], *listen))
+	}
+	if args[0] == "fuzz" {
+		config := fuzzer.DefaultConfig
+		flags := flag.NewFlagSet("fuzz", flag.ExitOnError)
+		flags.Uint64Var(&config.Seed, "seed", config.Seed, "պատահական թվերի աղբյուրի սկզբնական արժեքը")
+		flags.IntVar(&config.Runs, "runs", config.Runs, "կատարումների քանակը")
+		flags.IntVar(&config.Steps, "steps", config.Steps, "մեկ կատարման քայլերի առավելագույն քանակը")
+		flags.IntVar(&config.Inputs, "inputs", config.Inputs, "ներածման թվերի առավելագույն քանակը")
+		flags.Parse(args[1:])
+		args = flags.Args()
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Նշված չէ ծրագրի ֆայլը։")
+			os.Exit(exitFailure)
+		}
+		os.Exit(fuzz(args[0], config))
+	}
+	if args[0] == "cover" {
+		c := coverSettings{}
+		flags := flag.NewFlagSet("cover", flag.ExitOnError)
+		flags.IntVar(&c.steps, "steps", 1000000, "մեկ կատարման քայլերի առավելագույն քանակը")
+		flags.StringVar(&c.profile, "profile", "", "ծածկույթը կուտակող ֆայլը")
+		flags.StringVar(&c.html, "html", "", "HTML հաշվետվության ֆայլը")
+		flags.StringVar(&c.lcov, "lcov", "", "LCOV հաշվետվության ֆայլը")
+		flags.Parse(args[1:])
+		args = flags.Args()
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Նշված չէ ծրագրի ֆայլը։")
+			os.Exit(exitFailure)
+		}
+		os.Exit(cover(args[0], args[1:], c))
+	}
+
+	s := settings{}
+	if args[0] == "run" {
+		flags := flag.NewFlagSet("run", flag.ExitOnError)
+		flags.StringVar(&s.dir, "dir", "", "ծրագրին հասանելի ֆայլերի պանակը")
+		flags.BoolVar(&s.harvard, "harvard", false, "ծրագիրը բեռնել առանձին հրամանների հիշողության մեջ")
+		flags.BoolVar(&s.selfModifying, "self-modifying", false, "թույլատրել ծրագրի հրամանների փոփոխումը")
+		flags.BoolVar(&s.trace, "trace", false, "կատարման իրադարձությունները գրանցել սխալների հոսքում")
+		flags.StringVar(&s.core, "core", "", "ծուղակի դեպքում մեքենայի վիճակը գրել ֆայլում")
+		flags.Parse(args[1:])
+		args = flags.Args()
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Նշված չէ ծրագրի ֆայլը։")
+		os.Exit(exitFailure)
+	}
 
-	execute(os.Args[1])
+	os.Exit(execute(args[0], args[1:], s))
 }
@@ -0,0 +1,268 @@
+package repl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"svm/assembler"
+	"svm/bytecode"
+	"svm/machine"
+	"unicode"
+)
+
+// REPL-ը ամեն մուտքագրված տողն ասեմբլացնում և անմիջապես կատարում է
+// մեքենայի վրա, որը պահպանում է իր վիճակը տողից տող։ Բոլոր ընդունված
+// տողերը կազմում են մեկ ծրագիր, որը նորից ասեմբլացվում է ամեն նոր տողի
+// հետ, իսկ դրա նոր հրամանները գրվում են մեքենայի հիշողության սկզբում
+// պահված կոդի տարածքում՝ նախորդներից հետո։ Այդպես նախկինում սահմանված
+// պիտակները հասանելի են հաջորդ տողերին։
+//
+// Պիտակով սկսվող տողը սկսում է ենթածրագրի սահմանում, որը շարունակվում է
+// մինչև դատարկ տողը և չի կատարվում։ Երկու կետով սկսվող տողերը REPL-ի
+// հրամաններ են՝ :regs, :mem, :reset և :load։
+type REPL struct {
+	input   *bufio.Reader
+	output  io.Writer
+	vm      *machine.Machine
+	source  string            // ընդունված տողերը
+	program *bytecode.Program // source-ի բայթկոդը
+}
+
+const (
+	codeSize = 0x1000    // կոդի տարածքի չափը, որից հետո սկսվում է ստեկը
+	maxSteps = 1_000_000 // մեկ տողի կատարման քայլերի առավելագույն քանակը
+	maxStack = 16        // ցուցադրվող ստեկի բառերի առավելագույն քանակը
+)
+
+// INPUT հրամանը թվերը կարդում է նույն input-ից
+func New(input io.Reader, output io.Writer) *REPL {
+	r := &REPL{input: bufio.NewReader(input), output: output}
+	r.reset()
+	return r
+}
+
+// նոր մեքենա դատարկ կոդի տարածքով
+func (r *REPL) reset() {
+	if r.vm != nil {
+		r.vm.Close()
+	}
+	r.vm = machine.NewMachine(machine.WithStdin(r.input), machine.WithStdout(r.output), machine.WithStderr(r.output))
+	r.vm.Load(make([]byte, codeSize))
+	r.source, r.program = "", &bytecode.Program{}
+}
+
+// կարդալ ու կատարել տողերը մինչև input-ի ավարտը
+func (r *REPL) Run() error {
+	defer func() { r.vm.Close() }()
+	var definition strings.Builder
+	for {
+		if definition.Len() == 0 {
+			fmt.Fprint(r.output, "svm> ")
+		} else {
+			fmt.Fprint(r.output, "...> ")
+		}
+		line, err := r.input.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if err != nil && line == "" {
+			fmt.Fprintln(r.output)
+			if definition.Len() > 0 {
+				r.define(definition.String())
+			}
+			return nil
+		}
+		line = strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case definition.Len() > 0 && trimmed == "":
+			r.define(definition.String())
+			definition.Reset()
+		case definition.Len() > 0:
+			definition.WriteString(line + "\n")
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, ":"):
+			r.command(strings.Fields(trimmed))
+		case isLabel(trimmed):
+			definition.WriteString(line + "\n")
+		default:
+			r.execute(line + "\n")
+		}
+	}
+}
+
+// արդյոք տողը սկսվում է պիտակով
+func isLabel(line string) bool {
+	name, _, found := strings.Cut(line, ":")
+	if !found || name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ավելացնել chunk տողերը ծրագրին և դրանց հրամանները գրել կոդի
+// տարածքում. վերադարձնում է նոր հրամանների սկիզբն ու ավարտը
+func (r *REPL) add(chunk string) (int, int, error) {
+	// ասեմբլերը պահում է պիտակի առաջին սահմանումը, այնպես որ կրկնակի
+	// սահմանումն աննկատ կմնար
+	for _, line := range strings.Split(chunk, "\n") {
+		line = strings.TrimSpace(line)
+		if !isLabel(line) {
+			continue
+		}
+		name, _, _ := strings.Cut(line, ":")
+		if _, exists := r.program.Symbols[name]; exists {
+			return 0, 0, fmt.Errorf("Պիտակն արդեն սահմանված է. %s", name)
+		}
+	}
+	program, err := assembler.AssembleReader(strings.NewReader(r.source + chunk))
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(program.Code) > codeSize {
+		return 0, 0, fmt.Errorf("Կոդի տարածքը սպառված է")
+	}
+	start, end := len(r.program.Code), len(program.Code)
+	if err := r.vm.WriteMemory(int32(start), program.Code[start:]); err != nil {
+		return 0, 0, err
+	}
+	r.source, r.program = r.source+chunk, program
+	return start, end, nil
+}
+
+// ավելացնել սահմանումը՝ առանց այն կատարելու
+func (r *REPL) define(definition string) {
+	if _, _, err := r.add(definition); err != nil {
+		fmt.Fprintln(r.output, err.Error())
+	}
+}
+
+// ավելացնել ու կատարել line տողը, ապա արտածել ստեկը
+func (r *REPL) execute(line string) {
+	start, end, err := r.add(line)
+	if err != nil {
+		fmt.Fprintln(r.output, err.Error())
+		return
+	}
+	r.jump(start)
+
+	for steps := 0; r.vm.Registers().IP != int16(end); steps++ {
+		if steps == maxSteps {
+			fmt.Fprintf(r.output, "Կատարումը կանգնեցվեց %d քայլից հետո\n", maxSteps)
+			break
+		}
+		running, err := r.vm.Step()
+		if err != nil {
+			fmt.Fprintln(r.output, err.Error())
+			break
+		}
+		if !running {
+			fmt.Fprintf(r.output, "Ծրագիրն ավարտվեց %d կոդով\n", r.vm.ExitCode())
+			break
+		}
+	}
+	// հաջորդ տողը կատարվում է այս տողի հրամաններից հետո
+	r.jump(end)
+	r.showStack()
+}
+
+// IP-ն դնել address հասցեին
+func (r *REPL) jump(address int) {
+	registers := r.vm.Registers()
+	registers.IP = int16(address)
+	r.vm.SetRegisters(registers)
+}
+
+// արտածել ստեկի բառերը՝ գագաթը վերջում
+func (r *REPL) showStack() {
+	const base = codeSize + 1
+	top := r.vm.Registers().SP
+	start := max(base, top-4*maxStack)
+	start += (4 - (start-base)%4) % 4
+
+	words := []string{}
+	if start > base {
+		words = append(words, "…")
+	}
+	for address := start; address+4 <= top; address += 4 {
+		word, err := r.vm.ReadMemory(int32(address), 4)
+		if err != nil {
+			break
+		}
+		words = append(words, fmt.Sprint(int32(binary.LittleEndian.Uint32(word))))
+	}
+	fmt.Fprintf(r.output, "[%s]\n", strings.Join(words, " "))
+}
+
+// կատարել REPL-ի հրամանը
+func (r *REPL) command(fields []string) {
+	switch fields[0] {
+	case ":regs":
+		registers := r.vm.Registers()
+		fmt.Fprintf(r.output, "IP=%04x SP=%04x FP=%04x %s\n", registers.IP, registers.SP, registers.FP, registers.Mode)
+	case ":mem":
+		if err := r.dump(fields[1:]); err != nil {
+			fmt.Fprintln(r.output, err.Error())
+		}
+	case ":reset":
+		r.reset()
+	case ":load":
+		if len(fields) != 2 {
+			fmt.Fprintln(r.output, "Օգտագործումը. :load ֆայլ.asm")
+			return
+		}
+		text, err := os.ReadFile(fields[1])
+		if err != nil {
+			fmt.Fprintln(r.output, "Չհաջողվեց բացել ծրագրի տեքստի ֆայլը։")
+			return
+		}
+		source := string(text)
+		if !strings.HasSuffix(source, "\n") {
+			source += "\n"
+		}
+		r.define(source)
+	default:
+		fmt.Fprintf(r.output, "Անծանոթ հրաման %s. հասանելի են :regs, :mem հասցե [քանակ], :reset, :load ֆայլ.asm\n", fields[0])
+	}
+}
+
+// :mem հասցե [քանակ]. արտածել հիշողության բայթերը
+func (r *REPL) dump(arguments []string) error {
+	if len(arguments) == 0 || len(arguments) > 2 {
+		return fmt.Errorf("Օգտագործումը. :mem հասցե [քանակ]")
+	}
+	address, err := strconv.ParseInt(arguments[0], 0, 32)
+	if err != nil {
+		return fmt.Errorf("Սխալ հասցե. %s", arguments[0])
+	}
+	count := int64(16)
+	if len(arguments) == 2 {
+		if count, err = strconv.ParseInt(arguments[1], 0, 32); err != nil || count < 0 {
+			return fmt.Errorf("Սխալ քանակ. %s", arguments[1])
+		}
+	}
+
+	bytes, err := r.vm.ReadMemory(int32(address), int(count))
+	if err != nil {
+		return err
+	}
+	for k := 0; k < len(bytes); k += 16 {
+		fmt.Fprintf(r.output, "%04x:", int(address)+k)
+		for _, b := range bytes[k:min(k+16, len(bytes))] {
+			fmt.Fprintf(r.output, " %02x", b)
+		}
+		fmt.Fprintln(r.output)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// կատարել input տողերը և վերադարձնել արտածումը
+func session(t *testing.T, input string) string {
+	var output strings.Builder
+	if err := New(strings.NewReader(input), &output).Run(); err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	return output.String()
+}
+
+// ստուգել, որ output-ը պարունակում է expected տողերը՝ նույն հերթականությամբ
+func expectLines(t *testing.T, output string, expected ...string) {
+	t.Helper()
+	rest := output
+	for _, line := range expected {
+		k := strings.Index(rest, line)
+		if k == -1 {
+			t.Fatalf("Սպասվում է %q, բայց ստացվել է\n%s", line, output)
+		}
+		rest = rest[k+len(line):]
+	}
+}
+
+func TestSession(t *testing.T) {
+	output := session(t, `PUSH 3
+PUSH 5
+max:
+  PUSH [FP - 16]
+  PUSH [FP - 12]
+  GT
+  JZ second
+  PUSH [FP - 16]
+  RET 2
+second:
+  PUSH [FP - 12]
+  RET 2
+
+CALL max
+PUSH 2
+MUL
+PRINT
+max:
+  RET
+
+:regs
+:mem 0 6
+`)
+	expectLines(t, output,
+		"svm> [3]\n",
+		"svm> [3 5]\n",
+		"svm> ...> ",
+		"svm> [5]\n",
+		"svm> [5 2]\n",
+		"svm> [10]\n",
+		"svm> 10\n[]\n",
+		"Պիտակն արդեն սահմանված է. max\n",
+		"IP=", "SP=1001 FP=0000",
+		"0000: 41 03 00 00 00 41\n")
+}
+
+func TestErrors(t *testing.T) {
+	output := session(t, `PUSH 1
+PUSH 0
+DIV
+JUMP nowhere
+INPUT
+42
+HALT 3
+:reset
+:unknown
+`)
+	expectLines(t, output,
+		"[1 0]\n",
+		"բաժանում զրոյի վրա\n[]\n",
+		"Պիտակը սահմանված չէ. nowhere\n",
+		"[42]\n",
+		"Ծրագիրն ավարտվեց 3 կոդով\n[42]\n",
+		"Անծանոթ հրաման :unknown")
+}
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "square.asm")
+	if err := os.WriteFile(file, []byte("square:\n  PUSH [FP - 12]\n  PUSH [FP - 12]\n  MUL\n  RET 1"), 0o644); err != nil {
+		t.Fatalf("Չկարողացա ստեղծել ֆայլը։ (%v)", err)
+	}
+	output := session(t, ":load "+file+"\nPUSH 7\nCALL square\n:reset\nCALL square\n")
+	expectLines(t, output,
+		"[7]\n",
+		"[49]\n",
+		"Պիտակը սահմանված չէ. square\n")
+}
+
+func TestInfiniteLoop(t *testing.T) {
+	output := session(t, "loop:\n  JUMP loop\n\nJUMP loop\nPUSH 1\n")
+	expectLines(t, output, "Կատարումը կանգնեցվեց 1000000 քայլից հետո\n", "[1]\n")
+}
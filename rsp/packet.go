@@ -0,0 +1,99 @@
+package rsp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GDB-ի հեռավոր սերիական պրոտոկոլի (RSP) փաթեթներն ունեն $տվյալներ#cc
+// տեսքը, որտեղ cc-ն տվյալների բայթերի գումարն է 256-ի մոդուլով՝ երկու
+// տասնվեցական թվանշանով։ Ստացողը հաստատում է փաթեթը + նշանով կամ
+// պահանջում է այն կրկին՝ - նշանով։ Կատարման ընթացքում հաճախորդը կարող է
+// ուղարկել 0x03 բայթը՝ ծրագիրը կանգնեցնելու համար։
+
+// ընդհատման բայթը
+const interrupt = 0x03
+
+// կապից կարդացված հաղորդագրությունը
+type message struct {
+	packet    string // փաթեթի տվյալները
+	interrupt bool   // 0x03 ընդհատում
+	corrupted bool   // փաթեթի ստուգիչ գումարը սխալ է
+}
+
+// կարդալ հերթական փաթեթը կամ ընդհատումը՝ բաց թողնելով հաստատումները
+func readMessage(reader *bufio.Reader) (message, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return message{}, err
+		}
+		switch b {
+		case interrupt:
+			return message{interrupt: true}, nil
+		case '$':
+			data, err := reader.ReadString('#')
+			if err != nil {
+				return message{}, err
+			}
+			data = data[:len(data)-1]
+			var sum [2]byte
+			if _, err := io.ReadFull(reader, sum[:]); err != nil {
+				return message{}, err
+			}
+			if fmt.Sprintf("%02x", checksum(data)) != strings.ToLower(string(sum[:])) {
+				return message{corrupted: true}, nil
+			}
+			return message{packet: unescape(data)}, nil
+		}
+	}
+}
+
+// տվյալների բայթերի գումարը
+func checksum(data string) byte {
+	var sum byte
+	for k := 0; k < len(data); k++ {
+		sum += data[k]
+	}
+	return sum
+}
+
+// փաթեթի տվյալներում }-ով նշված բայթը 0x20-ով XOR արված է
+func unescape(data string) string {
+	if !strings.Contains(data, "}") {
+		return data
+	}
+	var b strings.Builder
+	for k := 0; k < len(data); k++ {
+		if data[k] == '}' && k+1 < len(data) {
+			k++
+			b.WriteByte(data[k] ^ 0x20)
+		} else {
+			b.WriteByte(data[k])
+		}
+	}
+	return b.String()
+}
+
+// նշել փաթեթում հատուկ իմաստ ունեցող բայթերը
+func escape(data string) string {
+	var b strings.Builder
+	for k := 0; k < len(data); k++ {
+		switch data[k] {
+		case '$', '#', '}', '*':
+			b.WriteByte('}')
+			b.WriteByte(data[k] ^ 0x20)
+		default:
+			b.WriteByte(data[k])
+		}
+	}
+	return b.String()
+}
+
+// գրել data տվյալներով փաթեթը
+func writePacket(writer io.Writer, data string) error {
+	_, err := fmt.Fprintf(writer, "$%s#%02x", data, checksum(data))
+	return err
+}
@@ -0,0 +1,441 @@
+package rsp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"svm/machine"
+)
+
+// Server-ը GDB-ի հեռավոր սերիական պրոտոկոլով կառավարում է մեքենան.
+// հաճախորդը կարող է կարդալ ու փոխել IP, SP, FP ռեգիստրներն ու
+// հիշողությունը, դնել կանգառի կետեր, կատարել ծրագիրը քայլ առ քայլ կամ
+// մինչև կանգառի կետը։ Ռեգիստրների նկարագրությունը հաճախորդը ստանում է
+// qXfer:features:read հարցմամբ։
+type Server struct {
+	vm          *machine.Machine
+	writer      io.Writer
+	messages    chan message
+	pending     []message      // կատարման ընթացքում ստացված փաթեթները
+	closed      bool           // հաճախորդը փակել է կապը
+	noAck       bool           // հաստատումներն անջատված են
+	breakpoints map[int16]bool // կանգառի կետերի հասցեները
+	status      string         // վերջին կանգառի պատասխանը
+	exited      bool           // ծրագիրն ավարտվել է
+	final       string         // c-ի և s-ի պատասխանը ավարտից հետո
+}
+
+func NewServer(vm *machine.Machine) *Server {
+	return &Server{vm: vm, breakpoints: map[int16]bool{}, status: "S05"}
+}
+
+// ռեգիստրների նկարագրությունը GDB-ի համար
+const targetDescription = `<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+  <feature name="org.svm.core">
+    <reg name="ip" bitsize="16" type="code_ptr" regnum="0"/>
+    <reg name="sp" bitsize="16" type="data_ptr" regnum="1"/>
+    <reg name="fp" bitsize="16" type="data_ptr" regnum="2"/>
+  </feature>
+</target>
+`
+
+// պատասխանի առավելագույն չափը, որը հայտարարվում է qSupported-ում
+const packetSize = 0x4000
+
+// GDB-ի ազդանշանների համարները
+const (
+	signalInterrupt     = 2
+	signalIllegal       = 4
+	signalTrap          = 5
+	signalAbort         = 6
+	signalFloatingPoint = 8
+	signalSegmentation  = 11
+	signalCPULimit      = 24
+)
+
+// ծուղակին համապատասխանող ազդանշանը
+func signal(trap *machine.Trap) int {
+	switch trap.Code {
+	case machine.TrapDivisionByZero, machine.TrapOverflow:
+		return signalFloatingPoint
+	case machine.TrapMemoryBounds, machine.TrapPageFault, machine.TrapWriteProtected,
+		machine.TrapStackOverflow, machine.TrapOutOfMemory:
+		return signalSegmentation
+	case machine.TrapInvalidOpcode, machine.TrapPrivileged, machine.TrapInvalidSystemCall:
+		return signalIllegal
+	case machine.TrapStepLimit:
+		return signalCPULimit
+	}
+	return signalAbort
+}
+
+// մշակել connection-ից ստացված հարցումները, քանի դեռ հաճախորդը չի
+// ուղարկել k կամ D կամ չի փակել կապը
+func (s *Server) Serve(connection io.ReadWriter) error {
+	s.writer = connection
+	s.messages = make(chan message)
+	done := make(chan struct{})
+	defer close(done)
+	failure := make(chan error, 1)
+	go func() {
+		defer close(s.messages)
+		reader := bufio.NewReader(connection)
+		for {
+			m, err := readMessage(reader)
+			if err != nil {
+				failure <- err
+				return
+			}
+			select {
+			case s.messages <- m:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		m, ok := s.next()
+		if !ok {
+			if err := <-failure; !errors.Is(err, io.EOF) {
+				return err
+			}
+			return nil
+		}
+		if m.interrupt {
+			continue
+		}
+		if m.corrupted {
+			if _, err := io.WriteString(s.writer, "-"); err != nil {
+				return err
+			}
+			continue
+		}
+		if !s.noAck {
+			if _, err := io.WriteString(s.writer, "+"); err != nil {
+				return err
+			}
+		}
+
+		reply, quit := s.handle(m.packet)
+		if s.closed {
+			continue
+		}
+		if reply != nil {
+			if err := writePacket(s.writer, *reply); err != nil {
+				return err
+			}
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// հերթական հաղորդագրությունը
+func (s *Server) next() (message, bool) {
+	if len(s.pending) > 0 {
+		m := s.pending[0]
+		s.pending = s.pending[1:]
+		return m, true
+	}
+	m, ok := <-s.messages
+	return m, ok
+}
+
+func reply(format string, arguments ...any) *string {
+	text := fmt.Sprintf(format, arguments...)
+	return &text
+}
+
+// չաջակցվող հարցման պատասխանը
+var unsupported = reply("")
+
+// սխալի պատասխանը
+var failed = reply("E01")
+
+// մշակել packet հարցումը. վերադարձնում է պատասխանը (nil՝ եթե այն չկա)
+// և true, եթե սերվերը պետք է ավարտվի
+func (s *Server) handle(packet string) (*string, bool) {
+	if packet == "" {
+		return unsupported, false
+	}
+	command, arguments := packet[0], packet[1:]
+	switch command {
+	case '?':
+		return &s.status, false
+	case 'g':
+		return reply("%s", s.registers()), false
+	case 'G':
+		return s.setRegisters(arguments), false
+	case 'p':
+		return s.register(arguments), false
+	case 'P':
+		return s.setRegister(arguments), false
+	case 'm':
+		return s.readMemory(arguments), false
+	case 'M', 'X':
+		return s.writeMemory(arguments, command == 'X'), false
+	case 'Z', 'z':
+		return s.breakpoint(arguments, command == 'Z'), false
+	case 'c', 's':
+		return s.resume(arguments, command == 's'), false
+	case 'H', 'T':
+		// միակ հոսքը
+		return reply("OK"), false
+	case 'k':
+		return nil, true
+	case 'D':
+		return reply("OK"), true
+	case 'q', 'Q':
+		return s.query(packet), false
+	}
+	return unsupported, false
+}
+
+// q և Q հարցումները
+func (s *Server) query(packet string) *string {
+	switch {
+	case strings.HasPrefix(packet, "qSupported"):
+		return reply("PacketSize=%x;qXfer:features:read+;QStartNoAckMode+;swbreak+", packetSize)
+	case packet == "QStartNoAckMode":
+		s.noAck = true
+		return reply("OK")
+	case strings.HasPrefix(packet, "qXfer:features:read:target.xml:"):
+		return transfer(targetDescription, strings.TrimPrefix(packet, "qXfer:features:read:target.xml:"))
+	case packet == "qAttached":
+		return reply("1")
+	case packet == "qC":
+		return reply("QC1")
+	case packet == "qfThreadInfo":
+		return reply("m1")
+	case packet == "qsThreadInfo":
+		return reply("l")
+	}
+	return unsupported
+}
+
+// document-ի հատվածը qXfer-ի "offset,length" արգումենտով
+func transfer(document, arguments string) *string {
+	offset, length, ok := pair(arguments)
+	if !ok || offset < 0 || length < 0 {
+		return failed
+	}
+	if offset >= len(document) {
+		return reply("l")
+	}
+	part := document[offset:min(offset+length, len(document))]
+	if offset+len(part) == len(document) {
+		return reply("l%s", escape(part))
+	}
+	return reply("m%s", escape(part))
+}
+
+// տասնվեցական "a,b" զույգը
+func pair(text string) (int, int, bool) {
+	first, second, found := strings.Cut(text, ",")
+	a, errA := strconv.ParseInt(first, 16, 32)
+	b, errB := strconv.ParseInt(second, 16, 32)
+	return int(a), int(b), found && errA == nil && errB == nil
+}
+
+// ռեգիստրի արժեքը փոքր-վերջավոր տասնվեցական տեսքով
+func encodeRegister(value int16) string {
+	var data [2]byte
+	binary.LittleEndian.PutUint16(data[:], uint16(value))
+	return hex.EncodeToString(data[:])
+}
+
+func decodeRegister(text string) (int16, bool) {
+	data, err := hex.DecodeString(text)
+	if err != nil || len(data) != 2 {
+		return 0, false
+	}
+	return int16(binary.LittleEndian.Uint16(data)), true
+}
+
+// IP, SP և FP ռեգիստրների հասցեները Registers-ում՝ համարի կարգով
+func fields(registers *machine.Registers) []*int16 {
+	return []*int16{&registers.IP, &registers.SP, &registers.FP}
+}
+
+func (s *Server) registers() string {
+	registers := s.vm.Registers()
+	var b strings.Builder
+	for _, field := range fields(&registers) {
+		b.WriteString(encodeRegister(*field))
+	}
+	return b.String()
+}
+
+func (s *Server) setRegisters(arguments string) *string {
+	registers := s.vm.Registers()
+	targets := fields(&registers)
+	if len(arguments) != 4*len(targets) {
+		return failed
+	}
+	for k, field := range targets {
+		value, ok := decodeRegister(arguments[4*k : 4*k+4])
+		if !ok {
+			return failed
+		}
+		*field = value
+	}
+	s.vm.SetRegisters(registers)
+	return reply("OK")
+}
+
+func (s *Server) register(arguments string) *string {
+	registers := s.vm.Registers()
+	targets := fields(&registers)
+	number, err := strconv.ParseInt(arguments, 16, 32)
+	if err != nil || number < 0 || int(number) >= len(targets) {
+		return failed
+	}
+	return reply("%s", encodeRegister(*targets[number]))
+}
+
+func (s *Server) setRegister(arguments string) *string {
+	registers := s.vm.Registers()
+	targets := fields(&registers)
+	name, text, _ := strings.Cut(arguments, "=")
+	number, err := strconv.ParseInt(name, 16, 32)
+	value, ok := decodeRegister(text)
+	if err != nil || !ok || number < 0 || int(number) >= len(targets) {
+		return failed
+	}
+	*targets[number] = value
+	s.vm.SetRegisters(registers)
+	return reply("OK")
+}
+
+// m հարցման պատասխանում ամեն բայթը գրվում է երկու տասնվեցական թվանշանով,
+// ուստի երկարությունը սահմանափակված է packetSize-ի կեսով
+func (s *Server) readMemory(arguments string) *string {
+	address, length, ok := pair(arguments)
+	if !ok || length < 0 || length > packetSize/2 {
+		return failed
+	}
+	data, err := s.vm.ReadMemory(int32(address), length)
+	if err != nil {
+		return failed
+	}
+	return reply("%s", hex.EncodeToString(data))
+}
+
+// M-ի տվյալները տասնվեցական են, իսկ X-ինը՝ բինար
+func (s *Server) writeMemory(arguments string, binary bool) *string {
+	header, text, found := strings.Cut(arguments, ":")
+	address, length, ok := pair(header)
+	if !found || !ok {
+		return failed
+	}
+	data := []byte(text)
+	if !binary {
+		var err error
+		if data, err = hex.DecodeString(text); err != nil {
+			return failed
+		}
+	}
+	if len(data) != length {
+		return failed
+	}
+	if err := s.vm.WriteMemory(int32(address), data); err != nil {
+		return failed
+	}
+	return reply("OK")
+}
+
+// դնել կամ հեռացնել ծրագրային կանգառի կետը՝ "0,հասցե,տեսակ" արգումենտով
+func (s *Server) breakpoint(arguments string, insert bool) *string {
+	parts := strings.Split(arguments, ",")
+	if len(parts) < 2 || parts[0] != "0" {
+		return unsupported
+	}
+	address, err := strconv.ParseInt(parts[1], 16, 32)
+	if err != nil {
+		return failed
+	}
+	if insert {
+		s.breakpoints[int16(address)] = true
+	} else {
+		delete(s.breakpoints, int16(address))
+	}
+	return reply("OK")
+}
+
+// շարունակել կատարումը (կամ կատարել մեկ հրաման)՝ ըստ ցանկության
+// նախապես փոխելով IP-ն, և վերադարձնել կանգառի պատասխանը
+func (s *Server) resume(arguments string, step bool) *string {
+	if s.exited {
+		return &s.final
+	}
+	if arguments != "" {
+		address, err := strconv.ParseInt(arguments, 16, 32)
+		if err != nil {
+			return failed
+		}
+		registers := s.vm.Registers()
+		registers.IP = int16(address)
+		s.vm.SetRegisters(registers)
+	}
+
+	s.status = s.run(step)
+	return &s.status
+}
+
+func (s *Server) run(step bool) string {
+	for first := true; ; first = false {
+		if !first && s.breakpoints[s.vm.Registers().IP] {
+			return fmt.Sprintf("T%02xswbreak:;", signalTrap)
+		}
+		if s.interrupted() {
+			return fmt.Sprintf("S%02x", signalInterrupt)
+		}
+
+		registers := s.vm.Registers()
+		running, err := s.vm.Step()
+		var trap *machine.Trap
+		if errors.As(err, &trap) {
+			// հաճախորդին ցույց տալ ծուղակն առաջացրած հրամանից առաջ եղած
+			// ռեգիստրները. չմշակված ծուղակից հետո ծրագիրը չի շարունակվում
+			s.vm.SetRegisters(registers)
+			s.exited = true
+			s.final = fmt.Sprintf("X%02x", signal(trap))
+			return fmt.Sprintf("S%02x", signal(trap))
+		}
+		if !running {
+			s.exited = true
+			s.final = fmt.Sprintf("W%02x", uint8(s.vm.ExitCode()))
+			return s.final
+		}
+		if step {
+			return fmt.Sprintf("S%02x", signalTrap)
+		}
+	}
+}
+
+// ստուգել, արդյոք հաճախորդն ուղարկել է ընդհատում. մյուս
+// հաղորդագրությունները պահվում են կատարման ավարտից հետո մշակելու համար
+func (s *Server) interrupted() bool {
+	select {
+	case m, ok := <-s.messages:
+		if !ok {
+			s.closed = true
+			return true
+		}
+		if m.interrupt {
+			return true
+		}
+		s.pending = append(s.pending, m)
+	default:
+	}
+	return false
+}
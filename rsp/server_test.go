@@ -0,0 +1,202 @@
+package rsp
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"svm/assembler"
+	"svm/bytecode"
+	"svm/machine"
+	"testing"
+	"time"
+)
+
+// max(a, b) ենթածրագիրը և դրա կանչը 3 և 5 արգումենտներով
+const example = `  PUSH 3
+  PUSH 5
+  CALL max
+  PRINT
+  HALT
+max:
+  PUSH [FP - 16]
+  PUSH [FP - 12]
+  GT
+  JZ second
+  PUSH [FP - 16]
+  RET 2
+second:
+  PUSH [FP - 12]
+  RET 2
+`
+
+// հաճախորդը, որը սերվերի հետ խոսում է net.Pipe-ով
+type client struct {
+	t          *testing.T
+	connection net.Conn
+	replies    chan string
+	done       chan error
+}
+
+func connect(t *testing.T, source string, options ...machine.Option) (*client, *bytecode.Program) {
+	file := filepath.Join(t.TempDir(), "example.asm")
+	if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+		t.Fatalf("Չկարողացա ստեղծել ֆայլը։ (%v)", err)
+	}
+	program, err := assembler.AssembleProgram(file)
+	if err != nil {
+		t.Fatalf("Ասեմբլերի սխալ։ (%v)", err)
+	}
+	vm := machine.NewMachine(options...)
+	vm.LoadProgram(program)
+
+	server, connection := net.Pipe()
+	c := &client{t: t, connection: connection, replies: make(chan string, 10), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer(vm).Serve(server)
+		server.Close()
+	}()
+	go func() {
+		reader := bufio.NewReader(connection)
+		for {
+			m, err := readMessage(reader)
+			if err != nil {
+				close(c.replies)
+				return
+			}
+			c.replies <- m.packet
+		}
+	}()
+	t.Cleanup(func() { connection.Close() })
+	return c, program
+}
+
+// ուղարկել packet հարցումը և ստանալ պատասխանը
+func (c *client) request(packet string) string {
+	c.t.Helper()
+	if err := writePacket(c.connection, packet); err != nil {
+		c.t.Fatalf("Չկարողացա ուղարկել հարցումը։ (%v)", err)
+	}
+	return c.reply()
+}
+
+func (c *client) reply() string {
+	c.t.Helper()
+	select {
+	case reply, ok := <-c.replies:
+		if !ok {
+			c.t.Fatalf("Սերվերը փակեց կապը")
+		}
+		return reply
+	case <-time.After(10 * time.Second):
+		c.t.Fatalf("Պատասխանը չստացվեց")
+	}
+	return ""
+}
+
+// ստուգել հարցման պատասխանը
+func (c *client) expect(packet, expected string) {
+	c.t.Helper()
+	if reply := c.request(packet); reply != expected {
+		c.t.Errorf("%s. սպասվում է %q, բայց ստացվել է %q", packet, expected, reply)
+	}
+}
+
+func TestSession(t *testing.T) {
+	var output strings.Builder
+	c, program := connect(t, example, machine.WithStdout(&output))
+	symbols := program.Symbols
+
+	if reply := c.request("qSupported:swbreak+"); !strings.Contains(reply, "qXfer:features:read+") {
+		t.Errorf("Սխալ հնարավորություններ. %s", reply)
+	}
+	c.expect("QStartNoAckMode", "OK")
+
+	// նկարագրությունը կարդալ փոքր մասերով
+	var description strings.Builder
+	for offset := 0; ; offset += 0x40 {
+		reply := c.request("qXfer:features:read:target.xml:" + encodeHex(offset) + ",40")
+		description.WriteString(reply[1:])
+		if reply[0] == 'l' {
+			break
+		}
+	}
+	if description.String() != targetDescription {
+		t.Errorf("Սխալ նկարագրություն. %s", description.String())
+	}
+
+	c.expect("?", "S05")
+	// IP = 0, SP = FP = 0-ից սկսվող ստեկը ծրագրից հետո
+	size := len(program.Code)
+	c.expect("g", "0000"+encodeRegister(int16(size+1))+"0000")
+
+	max := encodeHex(symbols["max"])
+	c.expect("Z0,"+max+",1", "OK")
+	c.expect("c", "T05swbreak:;")
+	c.expect("p0", encodeRegister(int16(symbols["max"])))
+
+	// FP-ից առաջ արգումենտներն են՝ 3 և 5
+	fp := size + 1 + 16
+	c.expect("p2", encodeRegister(int16(fp)))
+	c.expect("m"+encodeHex(fp-16)+",8", "0300000005000000")
+	c.expect("M"+encodeHex(fp-16)+",4:09000000", "OK")
+	c.expect("m"+encodeHex(fp-16)+",4", "09000000")
+	c.expect("mffff,4", "E01")
+	c.expect("m0,7fffffff", "E01")
+
+	c.expect("s", "S05")
+	c.expect("p0", encodeRegister(int16(symbols["max"]+3)))
+	c.expect("p7", "E01")
+
+	c.expect("z0,"+max+",1", "OK")
+	c.expect("c", "W00")
+	if output.String() != "9\n" {
+		t.Errorf("Սպասվում է 9, բայց ստացվել է %q", output.String())
+	}
+	c.expect("vMustReplyEmpty", "")
+	c.expect("D", "OK")
+	if err := <-c.done; err != nil {
+		t.Errorf("Անսպասելի սխալ։ (%v)", err)
+	}
+}
+
+func TestRegistersAndTraps(t *testing.T) {
+	c, _ := connect(t, "  PUSH 1\n  PUSH 0\n  DIV\n  PRINT\n  HALT\n")
+	// ստեկը սկսվում է ծրագրի 13 բայթից հետո
+	c.expect("P0="+encodeRegister(5), "OK")
+	c.expect("g", encodeRegister(5)+encodeRegister(14)+"0000")
+	c.expect("G"+encodeRegister(0)+encodeRegister(14)+"0000", "OK")
+	c.expect("c", "S08")
+	// DIV-ից առաջ ստեկում երկու բառ կա
+	c.expect("g", encodeRegister(10)+encodeRegister(22)+"0000")
+	c.expect("?", "S08")
+	c.expect("c", "X08")
+	c.expect("s", "X08")
+	if err := writePacket(c.connection, "k"); err != nil {
+		t.Fatalf("Չկարողացա ուղարկել հարցումը։ (%v)", err)
+	}
+	if err := <-c.done; err != nil {
+		t.Errorf("Անսպասելի սխալ։ (%v)", err)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	c, _ := connect(t, "loop:\n  JUMP loop\n")
+	if err := writePacket(c.connection, "c"); err != nil {
+		t.Fatalf("Չկարողացա ուղարկել հարցումը։ (%v)", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := c.connection.Write([]byte{interrupt}); err != nil {
+		t.Fatalf("Չկարողացա ուղարկել ընդհատումը։ (%v)", err)
+	}
+	if reply := c.reply(); reply != "S02" {
+		t.Errorf("Սպասվում է S02, բայց ստացվել է %q", reply)
+	}
+	c.expect("p0", "0000")
+}
+
+func encodeHex(value int) string {
+	return fmt.Sprintf("%x", value)
+}
@@ -0,0 +1,168 @@
+package vm
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"svm/assembler"
+	"svm/bytecode"
+	"svm/machine"
+)
+
+// vm փաթեթը ստեկային մեքենան ներդնում է Go ծրագրերում. Compile-ը
+// ասեմբլացնում է ծրագրի տեքստը, Program.Call-ը կանչում է դրա մեկ
+// ենթածրագիր տրված արգումենտներով, իսկ Program.Run-ը կատարում է ամբողջ
+// ծրագիրը։ Ամեն կանչ կատարվում է նոր մեքենայի վրա։
+
+// ասեմբլացված ծրագիրը
+type Program struct {
+	program *bytecode.Program
+	options []machine.Option
+}
+
+// կանչերի կարգավորումը
+type Option func(*Program)
+
+// INPUT հրամանի համար թվերը կարդալ reader-ից. բոլոր կանչերը կարդում են
+// նույն հոսքից՝ շարունակելով նախորդ կանչի կանգառի տեղից
+func WithStdin(reader io.Reader) Option {
+	buffered := bufio.NewReader(reader)
+	return WithMachineOptions(machine.WithStdin(buffered))
+}
+
+// PRINT հրամանի արդյունքը գրել writer-ում
+func WithStdout(writer io.Writer) Option {
+	return WithMachineOptions(machine.WithStdout(writer))
+}
+
+// EPRINT հրամանի արդյունքը գրել writer-ում
+func WithStderr(writer io.Writer) Option {
+	return WithMachineOptions(machine.WithStderr(writer))
+}
+
+// մեքենան ստեղծել լրացուցիչ կարգավորումներով, օրինակ՝ machine.WithStepLimit
+func WithMachineOptions(options ...machine.Option) Option {
+	return func(p *Program) {
+		p.options = append(p.options, options...)
+	}
+}
+
+// ասեմբլացնել source ծրագրի տեքստը
+func Compile(source string, options ...Option) (*Program, error) {
+	program, err := assembler.AssembleReader(strings.NewReader(source))
+	if err != nil {
+		return nil, err
+	}
+	p := &Program{program: program}
+	for _, option := range options {
+		option(p)
+	}
+	return p, nil
+}
+
+// ծրագրի պիտակներն ու դրանց հասցեները
+func (p *Program) Symbols() map[string]int {
+	return maps.Clone(p.program.Symbols)
+}
+
+// ստուգել կատարման ընդհատումը այսքան քայլը մեկ
+const contextInterval = 1024
+
+// կատարել մեքենան մինչև done պայմանը, ծրագրի ավարտը կամ ctx-ի չեղարկումը.
+// վերադարձնում է true, եթե ծրագիրն ավարտվել է HALT-ով
+func execute(ctx context.Context, vm *machine.Machine, done func() bool) (bool, error) {
+	for steps := 0; ; steps++ {
+		if steps%contextInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+		}
+		running, err := vm.Step()
+		if err != nil {
+			return false, err
+		}
+		if !running {
+			return true, nil
+		}
+		if done() {
+			return false, nil
+		}
+	}
+}
+
+// կատարել ամբողջ ծրագիրը args արգումենտներով և վերադարձնել HALT n-ի
+// ավարտի կոդը
+func (p *Program) Run(ctx context.Context, args ...string) (int32, error) {
+	vm := machine.NewMachine(p.options...)
+	defer vm.Close()
+	vm.LoadProgram(p.program)
+	if err := vm.SetArguments(args); err != nil {
+		return 0, err
+	}
+	if _, err := execute(ctx, vm, func() bool { return false }); err != nil {
+		return 0, err
+	}
+	return vm.ExitCode(), nil
+}
+
+// Call-ը կանչում է name ենթածրագիրը՝ ստեկում կառուցելով կանչի կադրը
+// ինչպես CALL հրամանը. նախ arguments-ը՝ առաջինից վերջին, հետո վերադարձի
+// հասցեն և կանչողի FP-ն։ Վերադարձի հասցեն ծրագրի կոդից անմիջապես հետո է,
+// այնպես որ ենթածրագրի RET-ից հետո կատարումն ավարտվում է, և ստեկի
+// գագաթին մնացած արժեքը կանչի արդյունքն է։
+func (p *Program) Call(ctx context.Context, name string, arguments ...int32) (int32, error) {
+	address, ok := p.program.Symbols[name]
+	if !ok {
+		return 0, fmt.Errorf("Պիտակը սահմանված չէ. %s", name)
+	}
+	if functions, err := bytecode.Functions(p.program.Code); err == nil {
+		for _, function := range functions {
+			if function.Address == address && function.Arguments >= 0 && function.Arguments != len(arguments) {
+				return 0, fmt.Errorf("%s ենթածրագիրը սպասում է %d արգումենտ, բայց տրվել է %d", name, function.Arguments, len(arguments))
+			}
+		}
+	}
+
+	vm := machine.NewMachine(p.options...)
+	defer vm.Close()
+	vm.LoadProgram(p.program)
+
+	registers := vm.Registers()
+	back := int16(len(p.program.Code))
+	frame := make([]byte, 0, 4*len(arguments)+8)
+	for _, argument := range arguments {
+		frame = binary.LittleEndian.AppendUint32(frame, uint32(argument))
+	}
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(back))
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(registers.FP))
+	if err := vm.WriteMemory(int32(registers.SP), frame); err != nil {
+		return 0, err
+	}
+	caller := registers.FP
+	registers.SP += int16(len(frame))
+	registers.FP, registers.IP = registers.SP, int16(address)
+	vm.SetRegisters(registers)
+
+	returned := func() bool {
+		current := vm.Registers()
+		return current.IP == back && current.FP == caller
+	}
+	halted, err := execute(ctx, vm, returned)
+	if err != nil {
+		return 0, err
+	}
+	if halted {
+		return 0, fmt.Errorf("Ծրագիրն ավարտվեց մինչև %s ենթածրագրից վերադարձը", name)
+	}
+
+	top := vm.Registers().SP - 4
+	value, err := vm.ReadMemory(int32(top), 4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(value)), nil
+}
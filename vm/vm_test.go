@@ -0,0 +1,132 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"svm/machine"
+	"testing"
+	"time"
+)
+
+const example = `  CALL main
+  HALT 7
+main:
+  PUSH 0
+  RET
+; max(a, b)
+max:
+  PUSH [FP - 16]
+  PUSH [FP - 12]
+  GT
+  JZ second
+  PUSH [FP - 16]
+  RET 2
+second:
+  PUSH [FP - 12]
+  RET 2
+; factorial(n)
+factorial:
+  PUSH [FP - 12]
+  JZ one
+  PUSH [FP - 12]
+  PUSH [FP - 12]
+  PUSH 1
+  SUB
+  CALL factorial
+  MUL
+  RET 1
+one:
+  PUSH 1
+  RET 1
+; echo()-ն կարդում և արտածում է թիվը
+echo:
+  INPUT
+  PUSH [SP - 4]
+  PRINT
+  RET
+divide:
+  PUSH [FP - 16]
+  PUSH [FP - 12]
+  DIV
+  RET 2
+loop:
+  JUMP loop
+`
+
+func TestCall(t *testing.T) {
+	program, err := Compile(example)
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	if _, ok := program.Symbols()["max"]; !ok {
+		t.Errorf("Սպասվում է max պիտակը, բայց ստացվել է %v", program.Symbols())
+	}
+
+	ctx := context.Background()
+	for _, c := range []struct {
+		name      string
+		arguments []int32
+		expected  int32
+	}{
+		{"max", []int32{3, 5}, 5},
+		{"max", []int32{9, -2}, 9},
+		{"factorial", []int32{5}, 120},
+		{"factorial", []int32{0}, 1},
+	} {
+		result, err := program.Call(ctx, c.name, c.arguments...)
+		if err != nil || result != c.expected {
+			t.Errorf("%s%v. սպասվում է %d, բայց ստացվել է %d (%v)", c.name, c.arguments, c.expected, result, err)
+		}
+	}
+
+	if code, err := program.Run(ctx); err != nil || code != 7 {
+		t.Errorf("Սպասվում է 7 ավարտի կոդ, բայց ստացվել է %d (%v)", code, err)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	program, err := Compile(example)
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	ctx := context.Background()
+
+	if _, err := program.Call(ctx, "min", 1, 2); err == nil {
+		t.Errorf("Սպասվում է սխալ անծանոթ պիտակի համար")
+	}
+	if _, err := program.Call(ctx, "max", 1); err == nil {
+		t.Errorf("Սպասվում է սխալ արգումենտների քանակի համար")
+	}
+	var trap *machine.Trap
+	if _, err := program.Call(ctx, "divide", 1, 0); !errors.As(err, &trap) || trap.Code != machine.TrapDivisionByZero {
+		t.Errorf("Սպասվում է 0-ի բաժանում, բայց ստացվել է %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := program.Call(ctx, "loop"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Սպասվում է context.DeadlineExceeded, բայց ստացվել է %v", err)
+	}
+
+	if _, err := Compile("  PUSH\n"); err == nil {
+		t.Errorf("Սպասվում է ասեմբլերի սխալ")
+	}
+}
+
+func TestInputOutput(t *testing.T) {
+	var output strings.Builder
+	program, err := Compile(example, WithStdin(strings.NewReader("4 8")), WithStdout(&output))
+	if err != nil {
+		t.Fatalf("Անսպասելի սխալ։ (%v)", err)
+	}
+	// երկրորդ կանչը շարունակում է կարդալ նույն հոսքից
+	for _, expected := range []int32{4, 8} {
+		if result, err := program.Call(context.Background(), "echo"); err != nil || result != expected {
+			t.Errorf("Սպասվում է %d, բայց ստացվել է %d (%v)", expected, result, err)
+		}
+	}
+	if output.String() != "4\n8\n" {
+		t.Errorf("Սպասվում է 4 և 8, բայց ստացվել է %q", output.String())
+	}
+}